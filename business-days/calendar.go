@@ -0,0 +1,279 @@
+package business_days
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	marketCloseHour   = 16
+	marketCloseMinute = 0
+)
+
+// Calendar is a market calendar made of computed holiday rules plus any extra closures added at runtime.
+type Calendar struct {
+	Name     string
+	Location *time.Location
+	// Rules must not be changed once the calendar is used, the closures they compute are cached.
+	Rules []HolidayRule
+
+	mu    sync.RWMutex
+	extra map[time.Time]Holiday
+	// byYear caches the closures observed in each year, it is cleared when a closure is added.
+	byYear map[int][]Holiday
+}
+
+// ClosureType is whether the market is closed all day or closes early.
+type ClosureType int
+
+const (
+	FullClose ClosureType = iota
+	PartialClose
+)
+
+func (ct ClosureType) String() string {
+	if ct == PartialClose {
+		return "partial"
+	}
+	return "full"
+}
+
+// Holiday is a single full or partial market closure.
+type Holiday struct {
+	Name string
+	// Date is the actual date of the holiday and Observed is the date the market closes for it.
+	Date     time.Time
+	Observed time.Time
+	Closure  ClosureType
+	// CloseTime is when the market closes on a partial close, in the calendar's location.
+	CloseTime time.Time
+	// Source is the name of the calendar or holiday file the holiday came from.
+	Source string
+}
+
+func (c *Calendar) holiday(name string, actual, observed time.Time, earlyClose bool, hour, minute int, source string) Holiday {
+	h := Holiday{
+		Name:     name,
+		Date:     actual,
+		Observed: observed,
+		Closure:  FullClose,
+		Source:   source,
+	}
+	if earlyClose {
+		h.Closure = PartialClose
+		h.CloseTime = time.Date(observed.Year(), observed.Month(), observed.Day(), hour, minute, 00, 00, c.Location)
+	}
+	return h
+}
+
+// DefaultCalendar is the calendar used by IsHoliday and GetBusinessDay.
+var DefaultCalendar = NewNYSECalendar()
+
+// NewCalendar returns an empty calendar with the given rules.
+func NewCalendar(name string, location *time.Location, rules []HolidayRule) *Calendar {
+	if location == nil {
+		location = time.UTC
+	}
+	return &Calendar{
+		Name:     name,
+		Location: location,
+		Rules:    rules,
+		extra:    make(map[time.Time]Holiday),
+	}
+}
+
+// NewNYSECalendar returns the New York Stock Exchange calendar.
+func NewNYSECalendar() *Calendar {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		location = time.UTC
+	}
+	return NewCalendar("NYSE", location, NYSERules())
+}
+
+// NewFederalReserveCalendar returns the Federal Reserve calendar used to settle US dollars and Treasuries.
+func NewFederalReserveCalendar() *Calendar {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		location = time.UTC
+	}
+	return NewCalendar("FED", location, FederalReserveRules())
+}
+
+// NewTARGETCalendar returns the TARGET2 calendar used to settle euros.
+func NewTARGETCalendar() *Calendar {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		location = time.UTC
+	}
+	return NewCalendar("TARGET", location, TARGETRules())
+}
+
+// AddHoliday adds an extra full or early close to the calendar, replacing any closure already on that date.
+func (c *Calendar) AddHoliday(h HolidayDates) {
+	c.add(h, c.Name)
+}
+
+func (c *Calendar) add(h HolidayDates, source string) {
+	d := date(h.Year, time.Month(h.Month), h.Day)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.extra == nil {
+		c.extra = make(map[time.Time]Holiday)
+	}
+	c.extra[d] = c.holiday(h.Description, d, d, h.EarlyClose, h.CloseHour, h.CloseMinute, source)
+	c.byYear = nil
+}
+
+// HolidayOn returns the holiday observed on the date, or whose actual date it is.
+func (c *Calendar) HolidayOn(current time.Time) (*Holiday, bool) {
+	d := dateOf(current)
+	var actual *Holiday
+	// The actual date can be in the year before or after the observed one, e.g. New Years Day on December 31st.
+	for y := d.Year() - 1; y <= d.Year()+1; y++ {
+		for _, h := range c.closures(y) {
+			if h.Observed.Equal(d) {
+				return &h, true
+			}
+			if h.Date.Equal(d) && actual == nil {
+				actual = &h
+			}
+		}
+	}
+	return actual, actual != nil
+}
+
+// HolidaysInYear returns the holidays and early closes observed in the year, sorted by date.
+func (c *Calendar) HolidaysInYear(year int) []Holiday {
+	return append([]Holiday(nil), c.closures(year)...)
+}
+
+// IsHoliday reports whether the market is closed all day on the date, either on a holiday's observed date or on its
+// actual date.
+func (c *Calendar) IsHoliday(current time.Time) bool {
+	h, ok := c.HolidayOn(current)
+	return ok && h.Closure == FullClose
+}
+
+// IsEarlyClose reports whether the market closes early on the date and returns the closing time in the calendar's
+// location.
+func (c *Calendar) IsEarlyClose(current time.Time) (time.Time, bool) {
+	h, ok := c.HolidayOn(current)
+	if !ok || h.Closure != PartialClose || !h.Observed.Equal(dateOf(current)) {
+		return time.Time{}, false
+	}
+	return h.CloseTime, true
+}
+
+// IsBusinessDay reports whether the market is open, at least part of the day, on the date.
+func (c *Calendar) IsBusinessDay(current time.Time) bool {
+	return !isWeekend(current) && !c.IsHoliday(current)
+}
+
+// AddBusinessDays moves n business days from start, backwards when n is negative.
+func (c *Calendar) AddBusinessDays(start time.Time, n int) time.Time {
+	return AddJointBusinessDays(start, n, c)
+}
+
+// NextBusinessDay returns the first business day after the date.
+func (c *Calendar) NextBusinessDay(current time.Time) time.Time {
+	return AddJointBusinessDays(current, 1, c)
+}
+
+// PreviousBusinessDay returns the last business day before the date.
+func (c *Calendar) PreviousBusinessDay(current time.Time) time.Time {
+	return AddJointBusinessDays(current, -1, c)
+}
+
+// IsJointBusinessDay reports whether the date is a business day in every one of the calendars.
+func IsJointBusinessDay(current time.Time, calendars ...*Calendar) bool {
+	for _, c := range calendars {
+		if !c.IsBusinessDay(current) {
+			return false
+		}
+	}
+	return !isWeekend(current)
+}
+
+// AddJointBusinessDays moves n days from start counting only the days that are business days in every one of the
+// calendars.  The result keeps the time of day of start.
+func AddJointBusinessDays(start time.Time, n int, calendars ...*Calendar) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+
+	current := start
+	for n > 0 {
+		current = current.AddDate(0, 0, step)
+		if IsJointBusinessDay(current, calendars...) {
+			n--
+		}
+	}
+	return current
+}
+
+// closures returns the computed and extra closures observed in the year, sorted by observed date.  Extra closures
+// replace computed ones on the same date.  The result is cached and must not be modified.
+func (c *Calendar) closures(year int) []Holiday {
+	c.mu.RLock()
+	results, ok := c.byYear[year]
+	c.mu.RUnlock()
+	if ok {
+		return results
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if results, ok = c.byYear[year]; ok {
+		return results
+	}
+	results = c.computeClosures(year)
+	if c.byYear == nil {
+		c.byYear = make(map[int][]Holiday)
+	}
+	c.byYear[year] = results
+	return results
+}
+
+// computeClosures evaluates the rules for the year.  The lock must be held.
+func (c *Calendar) computeClosures(year int) []Holiday {
+	byDate := make(map[time.Time]Holiday)
+	for y := year - 1; y <= year+1; y++ {
+		for _, r := range c.Rules {
+			if !r.appliesTo(y) {
+				continue
+			}
+			actual, ok := r.Date(y)
+			if !ok {
+				continue
+			}
+			observed := actual
+			if r.Observed != nil {
+				if observed, ok = r.Observed(actual); !ok {
+					continue
+				}
+			}
+			if observed.Year() != year {
+				continue
+			}
+			byDate[observed] = c.holiday(r.Description, actual, observed, r.EarlyClose, r.CloseHour, r.CloseMinute, c.Name)
+		}
+	}
+
+	for d, h := range c.extra {
+		if d.Year() == year {
+			byDate[d] = h
+		}
+	}
+
+	results := make([]Holiday, 0, len(byDate))
+	for _, h := range byDate {
+		results = append(results, h)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Observed.Before(results[j].Observed)
+	})
+	return results
+}
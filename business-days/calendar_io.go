@@ -0,0 +1,382 @@
+package business_days
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/sirupsen/logrus"
+)
+
+// FileFormat is the format of a holiday file.
+type FileFormat string
+
+const (
+	FormatICS  FileFormat = "ics"
+	FormatJSON FileFormat = "json"
+	FormatCSV  FileFormat = "csv"
+
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04"
+	icsDate    = "20060102"
+	icsTime    = "20060102T150405"
+)
+
+var (
+	errUnknownFormat = errors.New("unknown holiday file format")
+	errMissingDate   = errors.New("holiday without a date")
+)
+
+// holidayRecord is a single row in a JSON or CSV holiday file.
+type holidayRecord struct {
+	Date        string `json:"date"`
+	Description string `json:"description"`
+	EarlyClose  bool   `json:"early_close,omitempty"`
+	CloseTime   string `json:"close_time,omitempty"`
+}
+
+func (r holidayRecord) holiday() (HolidayDates, error) {
+	if r.Date == "" {
+		return HolidayDates{}, errMissingDate
+	}
+	d, err := time.Parse(dateLayout, strings.TrimSpace(r.Date))
+	if err != nil {
+		return HolidayDates{}, err
+	}
+
+	h := HolidayDates{
+		Description: r.Description,
+		Year:        d.Year(),
+		Month:       int(d.Month()),
+		Day:         d.Day(),
+		EarlyClose:  r.EarlyClose || r.CloseTime != "",
+	}
+	if h.EarlyClose {
+		h.CloseHour = 13
+		if r.CloseTime != "" {
+			ct, err := time.Parse(timeLayout, strings.TrimSpace(r.CloseTime))
+			if err != nil {
+				return HolidayDates{}, err
+			}
+			h.CloseHour, h.CloseMinute = ct.Hour(), ct.Minute()
+		}
+	}
+	return h, nil
+}
+
+// FormatFromFileName returns the holiday file format from the file extension.
+func FormatFromFileName(name string) (FileFormat, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ics", ".ical", ".ifb", ".icalendar":
+		return FormatICS, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("%w: %s", errUnknownFormat, name)
+}
+
+// LoadFile merges the holidays and early closes in an ICS, JSON or CSV file into the calendar.
+func (c *Calendar) LoadFile(name string) error {
+	format, err := FormatFromFileName(name)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return c.load(file, format, filepath.Base(name))
+}
+
+// Load merges the holidays and early closes read from r into the calendar.
+func (c *Calendar) Load(r io.Reader, format FileFormat) error {
+	return c.load(r, format, c.Name)
+}
+
+func (c *Calendar) load(r io.Reader, format FileFormat, source string) error {
+	var (
+		holidays []HolidayDates
+		err      error
+	)
+	switch format {
+	case FormatICS:
+		holidays, err = ReadICS(r, c.Location)
+	case FormatJSON:
+		holidays, err = readJSON(r)
+	case FormatCSV:
+		holidays, err = readCSV(r)
+	default:
+		err = fmt.Errorf("%w: %s", errUnknownFormat, format)
+	}
+	if err != nil {
+		logrus.Error("load ", source, ": ", err.Error())
+		return err
+	}
+
+	for _, h := range holidays {
+		c.add(h, source)
+	}
+	logrus.Debug("Loaded ", len(holidays), " holidays from ", source, " into ", c.Name)
+	return nil
+}
+
+func readJSON(r io.Reader) ([]HolidayDates, error) {
+	var records []holidayRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+
+	holidays := make([]HolidayDates, 0, len(records))
+	for i, record := range records {
+		h, err := record.holiday()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		holidays = append(holidays, h)
+	}
+	return holidays, nil
+}
+
+// readCSV reads a CSV file with a header of date,description and optionally early_close and close_time.
+func readCSV(r io.Reader) ([]HolidayDates, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, header := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, errMissingDate
+	}
+
+	field := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	holidays := make([]HolidayDates, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		record := holidayRecord{
+			Date:        field(row, "date"),
+			Description: field(row, "description"),
+			CloseTime:   field(row, "close_time"),
+		}
+		if earlyClose := field(row, "early_close"); earlyClose != "" {
+			if record.EarlyClose, err = strconv.ParseBool(earlyClose); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+2, err)
+			}
+		}
+
+		h, err := record.holiday()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		holidays = append(holidays, h)
+	}
+	return holidays, nil
+}
+
+// ReadICS reads the events of an iCalendar file as holidays.  All day events are full closures of every date from
+// DTSTART up to, but not including, DTEND, and timed events are early closes at the event's start time.  UTC times
+// and times in a TZID's zone are converted to the location, which is UTC when it is nil.
+func ReadICS(r io.Reader, location *time.Location) ([]HolidayDates, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		holidays    []HolidayDates
+		inEvent     bool
+		description string
+		start, end  time.Time
+		allDay      bool
+	)
+	for i, line := range lines {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent, description, start, end = true, "", time.Time{}, time.Time{}
+		case name == "END" && value == "VEVENT":
+			if !inEvent {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("line %d: %w", i+1, errMissingDate)
+			}
+			if !allDay {
+				holidays = append(holidays, HolidayDates{Description: description, Year: start.Year(),
+					Month: int(start.Month()), Day: start.Day(), EarlyClose: true, CloseHour: start.Hour(),
+					CloseMinute: start.Minute()})
+				continue
+			}
+			// DTEND is exclusive, an event without one, or ending before it starts, is a single day.
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				holidays = append(holidays, HolidayDates{Description: description, Year: d.Year(),
+					Month: int(d.Month()), Day: d.Day()})
+			}
+		case !inEvent:
+			continue
+		case name == "SUMMARY":
+			description = unescapeICS(value)
+		case name == "DTSTART":
+			if start, allDay, err = parseICSTime(params, value, location); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		case name == "DTEND":
+			if end, _, err = parseICSTime(params, value, location); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+	}
+	return holidays, nil
+}
+
+// unfoldICS joins continuation lines (RFC 5545 section 3.1) and drops blank lines.
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func splitICSLine(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+	params := make(map[string]string)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseICSTime parses a DTSTART or DTEND value and reports whether it is an all day date.  UTC times, ending in Z,
+// and times with a TZID the system knows are converted to the location.  Other timed values are read as wall clock
+// times in the location.  Timed values are returned as the location's wall clock time in UTC, like the dates.
+func parseICSTime(params map[string]string, value string, location *time.Location) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icsDate) {
+		t, err := time.Parse(icsDate, value)
+		return t, true, err
+	}
+
+	zone := location
+	if utc, ok := strings.CutSuffix(value, "Z"); ok {
+		value, zone = utc, time.UTC
+	} else if tzid := params["TZID"]; tzid != "" {
+		if z, err := time.LoadLocation(tzid); err == nil {
+			zone = z
+		} else {
+			logrus.Warn("Unknown ICS time zone ", tzid, ", using ", location)
+		}
+	}
+	t, err := time.ParseInLocation(icsTime, value, zone)
+	if err != nil {
+		return t, false, err
+	}
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0,
+		time.UTC), false, nil
+}
+
+func unescapeICS(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+func escapeICS(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(value)
+}
+
+// WriteICS exports the calendar's closures from fromYear through toYear as an iCalendar file that can be
+// subscribed to from calendar apps.
+func (c *Calendar) WriteICS(w io.Writer, fromYear, toYear int) error {
+	bw := bufio.NewWriter(w)
+	write := func(line string) {
+		// Lines longer than 75 octets are folded onto continuation lines.
+		for len(line) > 75 {
+			cut := 75
+			for !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			_, _ = bw.WriteString(line[:cut] + "\r\n")
+			line = " " + line[cut:]
+		}
+		_, _ = bw.WriteString(line + "\r\n")
+	}
+
+	stamp := time.Now().UTC().Format(icsTime) + "Z"
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:-//keputils//business-days//EN")
+	write("CALSCALE:GREGORIAN")
+	write("METHOD:PUBLISH")
+	write("X-WR-CALNAME:" + escapeICS(c.Name))
+	write("X-WR-TIMEZONE:" + c.Location.String())
+	for year := fromYear; year <= toYear; year++ {
+		for _, h := range c.HolidaysInYear(year) {
+			write("BEGIN:VEVENT")
+			write(fmt.Sprintf("UID:%s-%s@keputils", h.Observed.Format(icsDate), strings.ToLower(c.Name)))
+			write("DTSTAMP:" + stamp)
+			if h.Closure == PartialClose {
+				// Times are written in UTC, a TZID would need a VTIMEZONE describing the zone's rules.
+				start := time.Date(year, h.Observed.Month(), h.Observed.Day(), h.CloseTime.Hour(), h.CloseTime.Minute(), 00, 00, c.Location)
+				end := time.Date(year, h.Observed.Month(), h.Observed.Day(), marketCloseHour, marketCloseMinute, 00, 00, c.Location)
+				write("DTSTART:" + start.UTC().Format(icsTime) + "Z")
+				write("DTEND:" + end.UTC().Format(icsTime) + "Z")
+				write("CATEGORIES:EARLY CLOSE")
+			} else {
+				write("DTSTART;VALUE=DATE:" + h.Observed.Format(icsDate))
+				write("DTEND;VALUE=DATE:" + h.Observed.AddDate(0, 0, 1).Format(icsDate))
+				write("CATEGORIES:HOLIDAY")
+			}
+			write("SUMMARY:" + escapeICS(h.Name))
+			write("TRANSP:TRANSPARENT")
+			write("END:VEVENT")
+		}
+	}
+	write("END:VCALENDAR")
+	return bw.Flush()
+}
@@ -0,0 +1,313 @@
+package business_days_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	businessdays "github.com/kpearce2430/keputils/business-days"
+)
+
+// TestCalendar_NYSERules checks the computed rules against the exchange's published holidays.
+func TestCalendar_NYSERules(t *testing.T) {
+	t.Parallel()
+	published := map[int][]string{
+		2019: {"2019-01-01", "2019-01-21", "2019-02-18", "2019-04-19", "2019-05-27", "2019-07-04", "2019-09-02", "2019-11-28", "2019-12-25"},
+		2020: {"2020-01-01", "2020-01-20", "2020-02-17", "2020-04-10", "2020-05-25", "2020-07-03", "2020-09-07", "2020-11-26", "2020-12-25"},
+		2021: {"2021-01-01", "2021-01-18", "2021-02-15", "2021-04-02", "2021-05-31", "2021-07-05", "2021-09-06", "2021-11-25", "2021-12-24"},
+		2022: {"2022-01-17", "2022-02-21", "2022-04-15", "2022-05-30", "2022-06-20", "2022-07-04", "2022-09-05", "2022-11-24", "2022-12-26"},
+		2023: {"2023-01-02", "2023-01-16", "2023-02-20", "2023-04-07", "2023-05-29", "2023-06-19", "2023-07-04", "2023-09-04", "2023-11-23", "2023-12-25"},
+		2024: {"2024-01-01", "2024-01-15", "2024-02-19", "2024-03-29", "2024-05-27", "2024-06-19", "2024-07-04", "2024-09-02", "2024-11-28", "2024-12-25"},
+		2025: {"2025-01-01", "2025-01-20", "2025-02-17", "2025-04-18", "2025-05-26", "2025-06-19", "2025-07-04", "2025-09-01", "2025-11-27", "2025-12-25"},
+		2026: {"2026-01-01", "2026-01-19", "2026-02-16", "2026-04-03", "2026-05-25", "2026-06-19", "2026-07-03", "2026-09-07", "2026-11-26", "2026-12-25"},
+	}
+
+	calendar := businessdays.NewNYSECalendar()
+	for year, dates := range published {
+		closed := make(map[string]bool)
+		for _, d := range dates {
+			closed[d] = true
+		}
+
+		// Every weekday of the year must be open unless it is published as a holiday.
+		for d := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC); d.Year() == year; d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+				continue
+			}
+			if calendar.IsHoliday(d) != closed[d.Format("2006-01-02")] {
+				t.Error("Holiday mismatch:", d.Format("2006-01-02"))
+			}
+		}
+	}
+}
+
+func TestCalendar_IsEarlyClose(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		Name string
+		Date string
+		Want bool
+	}{
+		{Name: "Day after Thanksgiving", Date: "2024-11-29", Want: true},
+		{Name: "Christmas Eve", Date: "2024-12-24", Want: true},
+		{Name: "July 3rd", Date: "2023-07-03", Want: true},
+		{Name: "July 3rd Holiday", Date: "2020-07-03", Want: false},
+		{Name: "Regular Day", Date: "2024-12-23", Want: false},
+	}
+
+	calendar := businessdays.NewNYSECalendar()
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			d, _ := time.Parse("2006-01-02", tc.Date)
+			closeTime, ok := calendar.IsEarlyClose(d)
+			if ok != tc.Want {
+				t.Log("Test:", tc.Name, "Failed", tc.Want)
+				t.Fail()
+			}
+			if ok && closeTime.Hour() != 13 {
+				t.Error("Bad close time:", closeTime)
+			}
+		})
+	}
+}
+
+func TestCalendar_Load(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		Name   string
+		Format businessdays.FileFormat
+		Data   string
+	}{
+		{
+			Name:   "ICS",
+			Format: businessdays.FormatICS,
+			Data: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250109\r\n" +
+				"SUMMARY:National Day of Mourning for\r\n  President Carter\r\nEND:VEVENT\r\nBEGIN:VEVENT\r\n" +
+				"DTSTART;TZID=America/New_York:20250110T140000\r\nSUMMARY:Early Close\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		},
+		{
+			Name:   "JSON",
+			Format: businessdays.FormatJSON,
+			Data: `[{"date":"2025-01-09","description":"National Day of Mourning for President Carter"},
+				{"date":"2025-01-10","description":"Early Close","close_time":"14:00"}]`,
+		},
+		{
+			Name:   "CSV",
+			Format: businessdays.FormatCSV,
+			Data: "date,description,early_close,close_time\n" +
+				"2025-01-09,National Day of Mourning for President Carter,,\n" +
+				"2025-01-10,Early Close,true,14:00\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			calendar := businessdays.NewNYSECalendar()
+			if err := calendar.Load(strings.NewReader(tc.Data), tc.Format); err != nil {
+				t.Fatal(err.Error())
+			}
+
+			if !calendar.IsHoliday(time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)) {
+				t.Error("January 9th should be a holiday")
+			}
+			if !calendar.IsHoliday(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Error("computed holidays should still apply")
+			}
+			closeTime, ok := calendar.IsEarlyClose(time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC))
+			if !ok || closeTime.Hour() != 14 {
+				t.Error("January 10th should close early at 14:00:", closeTime)
+			}
+		})
+	}
+}
+
+func TestCalendar_LoadFile(t *testing.T) {
+	t.Parallel()
+	name := filepath.Join(t.TempDir(), "closures.csv")
+	if err := os.WriteFile(name, []byte("date,description\n2012-10-29,Hurricane Sandy\n2012-10-30,Hurricane Sandy\n"), 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	calendar := businessdays.NewNYSECalendar()
+	if err := calendar.LoadFile(name); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !calendar.IsHoliday(time.Date(2012, 10, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Error("October 30th should be a holiday")
+	}
+
+	if err := calendar.LoadFile("closures.txt"); err == nil {
+		t.Error("expected an unknown format error")
+	}
+}
+
+func TestCalendar_WriteICS(t *testing.T) {
+	t.Parallel()
+	calendar := businessdays.NewNYSECalendar()
+	calendar.AddHoliday(businessdays.HolidayDates{Description: "National Day of Mourning, President Carter", Year: 2025, Month: 1, Day: 9})
+
+	var buf bytes.Buffer
+	if err := calendar.WriteICS(&buf, 2025, 2025); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Early closes are written in UTC, TZID would need a VTIMEZONE.
+	if strings.Contains(buf.String(), "TZID") || !strings.Contains(buf.String(), "DTSTART:20251224T180000Z") {
+		t.Error("Early closes should be UTC:", buf.String())
+	}
+
+	holidays, err := businessdays.ReadICS(&buf, calendar.Location)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// 10 holidays, 1 extra closure and 3 early closes.
+	if len(holidays) != 14 {
+		t.Error("Bad holiday count:", len(holidays))
+	}
+
+	reloaded := businessdays.NewCalendar("Reloaded", nil, nil)
+	for _, h := range holidays {
+		reloaded.AddHoliday(h)
+	}
+	for d := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC); d.Year() == 2025; d = d.AddDate(0, 0, 1) {
+		if reloaded.IsHoliday(d) != calendar.IsHoliday(d) {
+			t.Error("Holiday mismatch:", d.Format("2006-01-02"))
+		}
+		_, earlyClose := calendar.IsEarlyClose(d)
+		if _, ok := reloaded.IsEarlyClose(d); ok != earlyClose {
+			t.Error("Early close mismatch:", d.Format("2006-01-02"))
+		}
+	}
+	if holidays[1].Description != "National Day of Mourning, President Carter" {
+		t.Error("Bad description:", holidays[1].Description)
+	}
+}
+
+func TestCalendar_AddHolidayAfterUse(t *testing.T) {
+	t.Parallel()
+	calendar := businessdays.NewNYSECalendar()
+	d := time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)
+	if calendar.IsHoliday(d) {
+		t.Fatal("January 9th should not be a holiday yet")
+	}
+	calendar.AddHoliday(businessdays.HolidayDates{Description: "National Day of Mourning", Year: 2025, Month: 1, Day: 9})
+	if !calendar.IsHoliday(d) {
+		t.Error("January 9th should be a holiday once added")
+	}
+}
+
+func TestReadICS(t *testing.T) {
+	t.Parallel()
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20121029",
+		"DTEND;VALUE=DATE:20121031",
+		"SUMMARY:Hurricane Sandy",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20241224T180000Z",
+		"DTEND:20241224T210000Z",
+		"SUMMARY:Christmas Eve",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=America/Chicago:20241128T120000",
+		"SUMMARY:Thanksgiving Friday",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=Exchange Time:20250703T130000",
+		"SUMMARY:Independence Day Eve",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20250109",
+		"SUMMARY:National Day of Mourning",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	calendar := businessdays.NewNYSECalendar()
+	holidays, err := businessdays.ReadICS(strings.NewReader(ics), calendar.Location)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []businessdays.HolidayDates{
+		{Description: "Hurricane Sandy", Year: 2012, Month: 10, Day: 29},
+		{Description: "Hurricane Sandy", Year: 2012, Month: 10, Day: 30},
+		{Description: "Christmas Eve", Year: 2024, Month: 12, Day: 24, EarlyClose: true, CloseHour: 13},
+		{Description: "Thanksgiving Friday", Year: 2024, Month: 11, Day: 28, EarlyClose: true, CloseHour: 13},
+		{Description: "Independence Day Eve", Year: 2025, Month: 7, Day: 3, EarlyClose: true, CloseHour: 13},
+		{Description: "National Day of Mourning", Year: 2025, Month: 1, Day: 9},
+	}
+	if !reflect.DeepEqual(holidays, expected) {
+		t.Error("Bad holidays:", holidays)
+	}
+}
+
+func TestCalendar_HolidayOn(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		Name     string
+		Date     time.Time
+		Want     string
+		Observed time.Time
+		Closure  businessdays.ClosureType
+	}{
+		{Name: "Juneteenth", Date: time.Date(2024, 6, 19, 0, 0, 0, 0, time.UTC), Want: "Juneteenth", Observed: time.Date(2024, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{Name: "Observed", Date: time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC), Want: "Independence Day", Observed: time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC)},
+		{Name: "Actual", Date: time.Date(2026, 7, 4, 0, 0, 0, 0, time.UTC), Want: "Independence Day", Observed: time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC)},
+		{Name: "Early Close", Date: time.Date(2024, 12, 24, 15, 0, 0, 0, time.UTC), Want: "Christmas Eve", Observed: time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC), Closure: businessdays.PartialClose},
+		{Name: "Regular Day", Date: time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			h, ok := businessdays.HolidayOn(tc.Date)
+			if ok != (tc.Want != "") {
+				t.Fatal("Test:", tc.Name, "Failed", ok)
+			}
+			if !ok {
+				return
+			}
+			if h.Name != tc.Want || !h.Observed.Equal(tc.Observed) || h.Closure != tc.Closure || h.Source != "NYSE" {
+				t.Errorf("Bad holiday: %+v", h)
+			}
+		})
+	}
+}
+
+func TestCalendar_HolidaysInYear(t *testing.T) {
+	t.Parallel()
+	calendar := businessdays.NewNYSECalendar()
+	calendar.AddHoliday(businessdays.HolidayDates{Description: "National Day of Mourning for President Carter", Year: 2025, Month: 1, Day: 9})
+
+	holidays := calendar.HolidaysInYear(2025)
+	if len(holidays) != 14 {
+		t.Fatal("Bad holiday count:", len(holidays))
+	}
+
+	var full, partial int
+	for i, h := range holidays {
+		if i > 0 && !holidays[i-1].Observed.Before(h.Observed) {
+			t.Error("Holidays are not sorted:", h.Name)
+		}
+		switch h.Closure {
+		case businessdays.FullClose:
+			full++
+		case businessdays.PartialClose:
+			partial++
+		}
+	}
+	if full != 11 || partial != 3 {
+		t.Error("Bad closures:", full, partial)
+	}
+	if holidays[1].Name != "National Day of Mourning for President Carter" {
+		t.Error("Bad extra holiday:", holidays[1].Name)
+	}
+}
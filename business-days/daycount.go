@@ -0,0 +1,177 @@
+package business_days
+
+import (
+	"fmt"
+	"time"
+)
+
+// DayCount is a day count convention used to accrue interest and dividends between two dates.
+type DayCount int
+
+const (
+	Actual360 DayCount = iota
+	Actual365Fixed
+	ActualActualISDA
+	Thirty360US
+	Thirty360European
+)
+
+func (dc DayCount) String() string {
+	switch dc {
+	case Actual360:
+		return "Actual/360"
+	case Actual365Fixed:
+		return "Actual/365F"
+	case ActualActualISDA:
+		return "Actual/Actual ISDA"
+	case Thirty360US:
+		return "30/360 US"
+	case Thirty360European:
+		return "30E/360"
+	}
+	return fmt.Sprintf("DayCount(%d)", int(dc))
+}
+
+// Days returns the number of days between start and end under the convention.
+func (dc DayCount) Days(start, end time.Time) int {
+	switch dc {
+	case Thirty360US, Thirty360European:
+		return dc.thirty360(dateOf(start), dateOf(end))
+	}
+	return actualDays(start, end)
+}
+
+// YearFraction returns the fraction of a year between start and end under the convention.  It is negative when end
+// is before start.
+func (dc DayCount) YearFraction(start, end time.Time) float64 {
+	switch dc {
+	case Actual360:
+		return float64(actualDays(start, end)) / 360
+	case Actual365Fixed:
+		return float64(actualDays(start, end)) / 365
+	case ActualActualISDA:
+		return actualActualISDA(dateOf(start), dateOf(end))
+	case Thirty360US, Thirty360European:
+		return float64(dc.Days(start, end)) / 360
+	}
+	return 0
+}
+
+func actualDays(start, end time.Time) int {
+	return int(dateOf(end).Sub(dateOf(start)).Hours() / 24)
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func isLastDayOfFebruary(t time.Time) bool {
+	return t.Month() == time.February && t.AddDate(0, 0, 1).Month() == time.March
+}
+
+// actualActualISDA splits the period by calendar year, dividing the days in leap years by 366 and the rest by 365.
+func actualActualISDA(start, end time.Time) float64 {
+	if end.Before(start) {
+		return -actualActualISDA(end, start)
+	}
+
+	var fraction float64
+	for start.Year() < end.Year() {
+		next := date(start.Year()+1, time.January, 1)
+		fraction += float64(actualDays(start, next)) / daysInYear(start.Year())
+		start = next
+	}
+	return fraction + float64(actualDays(start, end))/daysInYear(start.Year())
+}
+
+func daysInYear(year int) float64 {
+	if isLeapYear(year) {
+		return 366
+	}
+	return 365
+}
+
+func (dc DayCount) thirty360(start, end time.Time) int {
+	d1, d2 := start.Day(), end.Day()
+	switch dc {
+	case Thirty360US:
+		if isLastDayOfFebruary(start) {
+			if isLastDayOfFebruary(end) {
+				d2 = 30
+			}
+			d1 = 30
+		}
+		if d2 == 31 && d1 >= 30 {
+			d2 = 30
+		}
+		if d1 == 31 {
+			d1 = 30
+		}
+	case Thirty360European:
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 {
+			d2 = 30
+		}
+	}
+	return 360*(end.Year()-start.Year()) + 30*(int(end.Month())-int(start.Month())) + d2 - d1
+}
+
+// BusinessDayConvention is how a date that falls on a non business day is moved to a business day.
+type BusinessDayConvention int
+
+const (
+	Unadjusted BusinessDayConvention = iota
+	Following
+	ModifiedFollowing
+	Preceding
+	ModifiedPreceding
+)
+
+func (bdc BusinessDayConvention) String() string {
+	switch bdc {
+	case Unadjusted:
+		return "Unadjusted"
+	case Following:
+		return "Following"
+	case ModifiedFollowing:
+		return "Modified Following"
+	case Preceding:
+		return "Preceding"
+	case ModifiedPreceding:
+		return "Modified Preceding"
+	}
+	return fmt.Sprintf("BusinessDayConvention(%d)", int(bdc))
+}
+
+// Adjust moves the date to a business day using the convention.  The modified conventions go the other way when
+// moving would cross into another month.
+func (c *Calendar) Adjust(current time.Time, convention BusinessDayConvention) time.Time {
+	if convention == Unadjusted || c.IsBusinessDay(current) {
+		return current
+	}
+
+	switch convention {
+	case Following:
+		return c.NextBusinessDay(current)
+	case ModifiedFollowing:
+		if next := c.NextBusinessDay(current); next.Month() == current.Month() {
+			return next
+		}
+		return c.PreviousBusinessDay(current)
+	case Preceding:
+		return c.PreviousBusinessDay(current)
+	case ModifiedPreceding:
+		if previous := c.PreviousBusinessDay(current); previous.Month() == current.Month() {
+			return previous
+		}
+		return c.NextBusinessDay(current)
+	}
+	return current
+}
+
+// AccrualFraction returns the year fraction between start and end after both dates are adjusted to business days.
+func (c *Calendar) AccrualFraction(start, end time.Time, dayCount DayCount, convention BusinessDayConvention) float64 {
+	return dayCount.YearFraction(c.Adjust(start, convention), c.Adjust(end, convention))
+}
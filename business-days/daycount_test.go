@@ -0,0 +1,74 @@
+package business_days_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	businessdays "github.com/kpearce2430/keputils/business-days"
+)
+
+func TestDayCount_YearFraction(t *testing.T) {
+	t.Parallel()
+	type dayCountTests struct {
+		Name     string
+		DayCount businessdays.DayCount
+		Start    time.Time
+		End      time.Time
+		Days     int
+		Fraction float64
+	}
+
+	tests := []dayCountTests{
+		{Name: "Actual/360", DayCount: businessdays.Actual360, Start: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Days: 182, Fraction: 182.0 / 360},
+		{Name: "Actual/365F", DayCount: businessdays.Actual365Fixed, Start: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Days: 182, Fraction: 182.0 / 365},
+		{Name: "Actual/Actual ISDA", DayCount: businessdays.ActualActualISDA, Start: time.Date(2003, 11, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2004, 5, 1, 0, 0, 0, 0, time.UTC), Days: 182, Fraction: 61.0/365 + 121.0/366},
+		{Name: "30/360 US", DayCount: businessdays.Thirty360US, Start: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), Days: 60, Fraction: 60.0 / 360},
+		{Name: "30/360 US February", DayCount: businessdays.Thirty360US, Start: time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 8, 31, 0, 0, 0, 0, time.UTC), Days: 180, Fraction: 0.5},
+		{Name: "30/360 US Day 31", DayCount: businessdays.Thirty360US, Start: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), Days: 76, Fraction: 76.0 / 360},
+		{Name: "30E/360", DayCount: businessdays.Thirty360European, Start: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), Days: 75, Fraction: 75.0 / 360},
+		{Name: "30E/360 February", DayCount: businessdays.Thirty360European, Start: time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 8, 31, 0, 0, 0, 0, time.UTC), Days: 182, Fraction: 182.0 / 360},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			if days := tc.DayCount.Days(tc.Start, tc.End); days != tc.Days {
+				t.Error("Bad days:", days)
+			}
+			if fraction := tc.DayCount.YearFraction(tc.Start, tc.End); math.Abs(fraction-tc.Fraction) > 1e-12 {
+				t.Error("Bad fraction:", fraction)
+			}
+		})
+	}
+}
+
+func TestCalendar_Adjust(t *testing.T) {
+	t.Parallel()
+	type adjustTests struct {
+		Name       string
+		Date       time.Time
+		Convention businessdays.BusinessDayConvention
+		Expected   time.Time
+	}
+
+	tests := []adjustTests{
+		{Name: "Business Day", Date: time.Date(2024, 5, 28, 0, 0, 0, 0, time.UTC), Convention: businessdays.Following, Expected: time.Date(2024, 5, 28, 0, 0, 0, 0, time.UTC)},
+		{Name: "Unadjusted", Date: time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC), Convention: businessdays.Unadjusted, Expected: time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC)},
+		{Name: "Following", Date: time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC), Convention: businessdays.Following, Expected: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "Modified Following", Date: time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC), Convention: businessdays.ModifiedFollowing, Expected: time.Date(2024, 3, 28, 0, 0, 0, 0, time.UTC)},
+		{Name: "Preceding", Date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Convention: businessdays.Preceding, Expected: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{Name: "Modified Preceding", Date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Convention: businessdays.ModifiedPreceding, Expected: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	calendar := businessdays.NewNYSECalendar()
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			if result := calendar.Adjust(tc.Date, tc.Convention); !result.Equal(tc.Expected) {
+				t.Log("Test:", tc.Name, "Failed:", result)
+				t.Fail()
+			}
+		})
+	}
+}
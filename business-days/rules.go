@@ -0,0 +1,202 @@
+package business_days
+
+import "time"
+
+// HolidayRule computes a holiday (or early close) for a given year.
+type HolidayRule struct {
+	Description string
+	// FirstYear and LastYear bound the years the rule applies to.  Zero means unbounded.
+	FirstYear int
+	LastYear  int
+	// Date returns the actual date of the holiday for the year, false when there is none.
+	Date func(year int) (time.Time, bool)
+	// Observed moves the actual date to the day the market is closed, false when it is not observed.
+	// A nil Observed means the holiday is observed on its actual date.
+	Observed func(actual time.Time) (time.Time, bool)
+	// EarlyClose marks the rule as a partial day closing at CloseHour:CloseMinute.
+	EarlyClose  bool
+	CloseHour   int
+	CloseMinute int
+}
+
+func (r HolidayRule) appliesTo(year int) bool {
+	if r.FirstYear != 0 && year < r.FirstYear {
+		return false
+	}
+	if r.LastYear != 0 && year > r.LastYear {
+		return false
+	}
+	return true
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 00, 00, 00, 00, time.UTC)
+}
+
+// dateOf strips the time of day, keeping the calendar date as it reads in the time's own location.
+func dateOf(t time.Time) time.Time {
+	return date(t.Year(), t.Month(), t.Day())
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// FixedDate is a holiday on the same month and day every year.
+func FixedDate(month time.Month, day int) func(int) (time.Time, bool) {
+	return func(year int) (time.Time, bool) {
+		return date(year, month, day), true
+	}
+}
+
+// NthWeekday is a holiday on the nth weekday of the month, e.g. the 3rd Monday of January.
+func NthWeekday(month time.Month, weekday time.Weekday, n int) func(int) (time.Time, bool) {
+	return func(year int) (time.Time, bool) {
+		first := date(year, month, 1)
+		offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+		return first.AddDate(0, 0, offset+7*(n-1)), true
+	}
+}
+
+// LastWeekday is a holiday on the last weekday of the month, e.g. the last Monday of May.
+func LastWeekday(month time.Month, weekday time.Weekday) func(int) (time.Time, bool) {
+	return func(year int) (time.Time, bool) {
+		last := date(year, month+1, 0)
+		offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+		return last.AddDate(0, 0, -offset), true
+	}
+}
+
+// EasterOffset is a holiday a number of days from Easter Sunday, e.g. -2 for Good Friday.
+func EasterOffset(days int) func(int) (time.Time, bool) {
+	return func(year int) (time.Time, bool) {
+		return Easter(year).AddDate(0, 0, days), true
+	}
+}
+
+// Easter returns Easter Sunday for the year using the anonymous Gregorian algorithm.
+func Easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
+
+// NearestWeekday observes a Saturday holiday on Friday and a Sunday holiday on Monday.
+func NearestWeekday(actual time.Time) (time.Time, bool) {
+	switch actual.Weekday() {
+	case time.Saturday:
+		return actual.AddDate(0, 0, -1), true
+	case time.Sunday:
+		return actual.AddDate(0, 0, 1), true
+	}
+	return actual, true
+}
+
+// NextMonday observes a Sunday holiday on Monday and drops a Saturday one.
+func NextMonday(actual time.Time) (time.Time, bool) {
+	switch actual.Weekday() {
+	case time.Saturday:
+		return actual, false
+	case time.Sunday:
+		return actual.AddDate(0, 0, 1), true
+	}
+	return actual, true
+}
+
+// NextWeekday observes a weekend holiday on the following Monday.
+func NextWeekday(actual time.Time) (time.Time, bool) {
+	switch actual.Weekday() {
+	case time.Saturday:
+		return actual.AddDate(0, 0, 2), true
+	case time.Sunday:
+		return actual.AddDate(0, 0, 1), true
+	}
+	return actual, true
+}
+
+// weekdayEarlyClose is an early close on a fixed date that only happens when the date is a weekday and the
+// related holiday is not observed on it.
+func weekdayEarlyClose(month time.Month, day int, skip func(time.Time) bool) func(int) (time.Time, bool) {
+	return func(year int) (time.Time, bool) {
+		d := date(year, month, day)
+		if isWeekend(d) || (skip != nil && skip(d)) {
+			return d, false
+		}
+		return d, true
+	}
+}
+
+// NYSERules are the regular holidays and early closes of the New York Stock Exchange.
+func NYSERules() []HolidayRule {
+	return []HolidayRule{
+		{Description: "New Years Day", Date: FixedDate(time.January, 1), Observed: NextMonday},
+		{Description: "Martin Luther King, Jr. Day", FirstYear: 1998, Date: NthWeekday(time.January, time.Monday, 3)},
+		{Description: "Washington's Birthday", Date: NthWeekday(time.February, time.Monday, 3)},
+		{Description: "Good Friday", Date: EasterOffset(-2)},
+		{Description: "Memorial Day", Date: LastWeekday(time.May, time.Monday)},
+		{Description: "Juneteenth", FirstYear: 2022, Date: FixedDate(time.June, 19), Observed: NearestWeekday},
+		{Description: "Independence Day", Date: FixedDate(time.July, 4), Observed: NearestWeekday},
+		{Description: "Labor Day", Date: NthWeekday(time.September, time.Monday, 1)},
+		{Description: "Thanksgiving", Date: NthWeekday(time.November, time.Thursday, 4)},
+		{Description: "Christmas", Date: FixedDate(time.December, 25), Observed: NearestWeekday},
+		{
+			Description: "Independence Day Eve", EarlyClose: true, CloseHour: 13,
+			// July 3rd is the observed holiday when the 4th falls on a Saturday.
+			Date: weekdayEarlyClose(time.July, 3, func(d time.Time) bool { return d.Weekday() == time.Friday }),
+		},
+		{
+			Description: "Day after Thanksgiving", EarlyClose: true, CloseHour: 13,
+			Date: func(year int) (time.Time, bool) {
+				thanksgiving, _ := NthWeekday(time.November, time.Thursday, 4)(year)
+				return thanksgiving.AddDate(0, 0, 1), true
+			},
+		},
+		{
+			Description: "Christmas Eve", EarlyClose: true, CloseHour: 13,
+			// December 24th is the observed holiday when Christmas falls on a Saturday.
+			Date: weekdayEarlyClose(time.December, 24, func(d time.Time) bool { return d.Weekday() == time.Friday }),
+		},
+	}
+}
+
+// FederalReserveRules are the holidays of the Federal Reserve, which settles US dollar payments and Treasuries.  The
+// Fed observes a Sunday holiday on Monday but does not close for a Saturday one.
+func FederalReserveRules() []HolidayRule {
+	return []HolidayRule{
+		{Description: "New Years Day", Date: FixedDate(time.January, 1), Observed: NextMonday},
+		{Description: "Martin Luther King, Jr. Day", FirstYear: 1986, Date: NthWeekday(time.January, time.Monday, 3)},
+		{Description: "Washington's Birthday", Date: NthWeekday(time.February, time.Monday, 3)},
+		{Description: "Memorial Day", Date: LastWeekday(time.May, time.Monday)},
+		{Description: "Juneteenth", FirstYear: 2022, Date: FixedDate(time.June, 19), Observed: NextMonday},
+		{Description: "Independence Day", Date: FixedDate(time.July, 4), Observed: NextMonday},
+		{Description: "Labor Day", Date: NthWeekday(time.September, time.Monday, 1)},
+		{Description: "Columbus Day", Date: NthWeekday(time.October, time.Monday, 2)},
+		{Description: "Veterans Day", Date: FixedDate(time.November, 11), Observed: NextMonday},
+		{Description: "Thanksgiving", Date: NthWeekday(time.November, time.Thursday, 4)},
+		{Description: "Christmas", Date: FixedDate(time.December, 25), Observed: NextMonday},
+	}
+}
+
+// TARGETRules are the closing days of the euro area's TARGET2 payment system.
+func TARGETRules() []HolidayRule {
+	return []HolidayRule{
+		{Description: "New Years Day", Date: FixedDate(time.January, 1)},
+		{Description: "Good Friday", Date: EasterOffset(-2)},
+		{Description: "Easter Monday", Date: EasterOffset(1)},
+		{Description: "Labour Day", Date: FixedDate(time.May, 1)},
+		{Description: "Christmas", Date: FixedDate(time.December, 25)},
+		{Description: "Christmas Holiday", Date: FixedDate(time.December, 26)},
+	}
+}
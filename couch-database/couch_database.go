@@ -11,9 +11,9 @@ import (
 	"net/url"
 	"os"
 
-	"github.com/kelseyhightower/envconfig"
 	couchdbclient "github.com/kpearce2430/keputils/couchdb-client"
 	"github.com/kpearce2430/keputils/http-client"
+	"github.com/kpearce2430/keputils/utils"
 	"github.com/segmentio/encoding/json"
 	"github.com/sirupsen/logrus"
 	"github.com/testcontainers/testcontainers-go"
@@ -22,6 +22,7 @@ import (
 
 var (
 	errInvalidStatusResponse = errors.New("invalid status response")
+	errDocumentNotFound      = errors.New("document not found")
 )
 
 type DatabaseStore[T interface{}] struct {
@@ -87,15 +88,14 @@ func (ds DatabaseStore[T]) DocumentURL(key string) (*url.URL, error) {
 }
 
 func GetDataStoreByDatabaseName[T interface{}](databaseName string) (*DatabaseStore[T], error) {
-	var dbConfig DatabaseConfig
-	err := envconfig.Process("", &dbConfig)
+	dbConfig, err := loadDatabaseConfig(utils.NewConfig(""))
 	if err != nil {
 		log.Println(err.Error())
 		return nil, err
 	}
 
 	dbConfig.DatabaseName = databaseName
-	datastore := NewDataStore[T](&dbConfig)
+	datastore := NewDataStore[T](dbConfig)
 	return &datastore, nil
 }
 
@@ -305,7 +305,8 @@ func (ds DatabaseStore[T]) DocumentCreate(key string, document *T) (string, erro
 	return couchDBResponse.Rev, nil
 }
 
-func (ds DatabaseStore[T]) DocumentGet(key string) (*T, error) {
+// documentGetRaw returns the stored JSON of the document, nil when it does not exist.
+func (ds DatabaseStore[T]) documentGetRaw(key string) ([]byte, error) {
 	documentUrl, err := ds.DocumentURL(key)
 	if err != nil {
 		logrus.Error("could not create document url for key:", key)
@@ -317,21 +318,29 @@ func (ds DatabaseStore[T]) DocumentGet(key string) (*T, error) {
 		return nil, err
 	}
 
-	var responseDocument T
 	switch statusCode {
 	case http.StatusOK:
-		err = json.Unmarshal(body, &responseDocument)
-		if err != nil {
-			logrus.Error(err.Error())
-			return nil, err
-		}
+		return body, nil
 	case http.StatusNotFound:
 		return nil, nil
 	default:
 		logrus.Error("Invalid status response:", statusCode)
 		return nil, errInvalidStatusResponse
 	}
+}
 
+func (ds DatabaseStore[T]) DocumentGet(key string) (*T, error) {
+	body, err := ds.documentGetRaw(key)
+	if err != nil || body == nil {
+		return nil, err
+	}
+
+	var responseDocument T
+	err = json.Unmarshal(body, &responseDocument)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
 	return &responseDocument, nil
 }
 
@@ -368,6 +377,113 @@ func (ds DatabaseStore[T]) DocumentUpdate(key string, revision string, document
 
 }
 
+// metadataPaths are CouchDB's id, which is the key, and revision, which changes on every update.  They are left out of
+// document patches.
+var metadataPaths = []string{"/_id", "/_rev"}
+
+// DocumentDiff returns the JSON Patch that changes before into after, comparing them as they are stored.
+func (ds DatabaseStore[T]) DocumentDiff(before, after *T) (utils.JSONPatch, error) {
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		return nil, err
+	}
+	afterData, err := json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+	return diffDocuments(beforeData, afterData)
+}
+
+// diffDocuments diffs two stored documents, leaving out the id and revision.
+func diffDocuments(before, after []byte) (utils.JSONPatch, error) {
+	patch, err := utils.DiffJSON(before, after)
+	if err != nil {
+		return nil, err
+	}
+	return utils.Filter(patch, func(o utils.PatchOperation) bool { return !utils.Contains(metadataPaths, o.Path) }), nil
+}
+
+// DocumentUpdateWithPatch updates the document like DocumentUpdate and logs, and returns, the JSON Patch from the
+// stored document to the new one for auditing.  A document that did not exist is patched from {}.
+func (ds DatabaseStore[T]) DocumentUpdateWithPatch(key string, revision string, document *T) (string, utils.JSONPatch, error) {
+	stored, err := ds.documentGetRaw(key)
+	if err != nil {
+		return "", nil, err
+	}
+	if stored == nil {
+		stored = []byte("{}")
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		logrus.Error(err.Error())
+		return "", nil, err
+	}
+	patch, err := diffDocuments(stored, data)
+	if err != nil {
+		logrus.Error(err.Error())
+		return "", nil, err
+	}
+
+	rev, err := ds.DocumentUpdate(key, revision, document)
+	if err != nil {
+		return "", nil, err
+	}
+
+	patchData, _ := json.Marshal(patch)
+	logrus.Info("Updated ", ds.databaseConfig.DatabaseName, "/", key, " to ", rev, ": ", string(patchData))
+	return rev, patch, nil
+}
+
+// DocumentPatch applies a JSON Patch to the stored document and saves it, returning the new revision.  The document
+// is saved with the revision it was read at, so a concurrent update fails rather than being overwritten, and test
+// operations can make the patch conditional.
+func (ds DatabaseStore[T]) DocumentPatch(key string, patch utils.JSONPatch) (string, error) {
+	stored, err := ds.documentGetRaw(key)
+	if err != nil {
+		return "", err
+	}
+	if stored == nil {
+		logrus.Error("Document not found:", key)
+		return "", errDocumentNotFound
+	}
+
+	patched, err := patch.Apply(stored)
+	if err != nil {
+		logrus.Error(err.Error())
+		return "", err
+	}
+	// The patched document must still be a T.
+	var document T
+	if err = json.Unmarshal(patched, &document); err != nil {
+		logrus.Error(err.Error())
+		return "", err
+	}
+
+	documentUrl, err := ds.DocumentURL(key)
+	if err != nil {
+		logrus.Error("could not create document url for key:", err.Error())
+		return "", err
+	}
+	statusCode, body, err := ds.callCouchDB(http.MethodPut, documentUrl, patched)
+	if err != nil {
+		return "", err
+	}
+
+	switch statusCode {
+	case http.StatusOK, http.StatusCreated:
+		var couchDBResponse couchdbclient.CouchDBResponse
+		err = json.Unmarshal(body, &couchDBResponse)
+		if err != nil {
+			logrus.Error(err.Error())
+			return "", err
+		}
+		return couchDBResponse.Rev, nil
+	}
+	logrus.Error("Invalid status response:", statusCode)
+	return "", errInvalidStatusResponse
+}
+
 func (ds DatabaseStore[T]) DocumentDelete(key string, revision string) (string, error) {
 	documentUrl, err := ds.DocumentURL(key)
 	if err != nil {
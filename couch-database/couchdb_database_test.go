@@ -2,12 +2,14 @@ package couch_database_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"testing"
 
 	couchdatabase "github.com/kpearce2430/keputils/couch-database"
+	"github.com/kpearce2430/keputils/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -74,6 +76,18 @@ func TestDatabaseConfig(t *testing.T) {
 	}
 }
 
+func TestDatabaseConfig_Defaults(t *testing.T) {
+	dbConfig, err := couchdatabase.LoadDatabaseConfig(utils.NewConfigFromMap("", map[string]string{"COUCHDB_DATABASE": "tester"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "tester", dbConfig.DatabaseName)
+	assert.Equal(t, "http://localhost:5984", dbConfig.CouchDBUrl)
+	assert.Equal(t, "admin", dbConfig.Username)
+	assert.Equal(t, "password", dbConfig.Password)
+
+	_, err = couchdatabase.LoadDatabaseConfig(utils.NewConfigFromMap("MY", map[string]string{}))
+	assert.ErrorContains(t, err, "MY_COUCHDB_DATABASE or COUCHDB_DATABASE is required")
+}
+
 func TestDataStore(t *testing.T) {
 	err := os.Setenv("MY_COUCHDB_DATABASE", "junk")
 	if err != nil {
@@ -261,3 +275,47 @@ func TestGetDataStoreByDatabaseName(t *testing.T) {
 		t.Error("db is nil")
 	}
 }
+
+func TestDatabaseStore_DocumentPatch(t *testing.T) {
+	url, ok := os.LookupEnv("COUCHDB_URL")
+	if !ok {
+		t.Fatal("COUCHDB_URL not set")
+	}
+	databaseStore := couchdatabase.New[TestDocument]("patches", url, "admin", "password")
+	if !databaseStore.DatabaseCreate() {
+		t.Fatal("Error creating a database")
+	}
+
+	rev, err := databaseStore.DocumentCreate("doc", &TestDocument{Name: "first", Value: 1})
+	assert.Nil(t, err)
+
+	rev, patch, err := databaseStore.DocumentUpdateWithPatch("doc", rev, &TestDocument{Name: "second", Value: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, utils.JSONPatch{{Op: "replace", Path: "/Name", Value: "second"}}, patch)
+
+	_, err = databaseStore.DocumentPatch("doc", utils.JSONPatch{
+		{Op: "test", Path: "/Name", Value: "first"},
+		{Op: "replace", Path: "/Value", Value: 2},
+	})
+	assert.NotNil(t, err, "test operation fails")
+
+	newRev, err := databaseStore.DocumentPatch("doc", utils.JSONPatch{
+		{Op: "test", Path: "/_rev", Value: rev},
+		{Op: "replace", Path: "/Value", Value: 2},
+	})
+	assert.Nil(t, err)
+	assert.NotEqual(t, rev, newRev)
+
+	document, err := databaseStore.DocumentGet("doc")
+	assert.Nil(t, err)
+	if assert.NotNil(t, document) {
+		assert.Equal(t, TestDocument{Id: "doc", Rev: newRev, Name: "second", Value: 2}, *document)
+	}
+
+	diff, err := databaseStore.DocumentDiff(&TestDocument{Id: "doc", Rev: "1", Name: "a"}, &TestDocument{Id: "doc", Rev: "2", Name: "a", Value: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, utils.JSONPatch{{Op: "replace", Path: "/Value", Value: json.Number("3")}}, diff)
+
+	_, err = databaseStore.DocumentPatch("missing", utils.JSONPatch{})
+	assert.NotNil(t, err)
+}
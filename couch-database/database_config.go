@@ -3,29 +3,39 @@ package couch_database
 import (
 	"fmt"
 
-	"github.com/kelseyhightower/envconfig"
 	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
 )
 
 type DatabaseConfig struct {
-	DatabaseName string `envconfig:"COUCHDB_DATABASE",required:"true"`
-	CouchDBUrl   string `envconfig:"COUCHDB_URL",default:"http://localhost:5984"`
-	Username     string `envconfig:"COUCHDB_USER",default:"admin"`
-	Password     string `envconfig:"COUCHDB_PASSWORD",default:"password"`
+	DatabaseName string
+	CouchDBUrl   string
+	Username     string
+	Password     string
 }
 
+// NewDatabaseConfig reads the configuration from COUCHDB_DATABASE, which is required, COUCHDB_URL, COUCHDB_USER and
+// COUCHDB_PASSWORD, each with the prefix first.  When prefix is empty the PREFIX environment variable is used.
 func NewDatabaseConfig(prefix string) (*DatabaseConfig, error) {
-	myprefix := prefix
-
-	// One last change to look for a prefix
-	if prefix == "" {
-		myprefix = utils.GetEnv("PREFIX", "")
-	}
+	return LoadDatabaseConfig(utils.NewConfig(prefix))
+}
 
-	var dbConfig DatabaseConfig
-	err := envconfig.Process(myprefix, &dbConfig)
+// LoadDatabaseConfig reads the configuration from cfg, see NewDatabaseConfig.  The configuration is returned, with
+// the defaults, even when there is an error.
+func LoadDatabaseConfig(cfg *utils.Config) (*DatabaseConfig, error) {
+	cfg.Required("COUCHDB_DATABASE")
+	return loadDatabaseConfig(cfg)
+}
 
-	return &dbConfig, err
+func loadDatabaseConfig(cfg *utils.Config) (*DatabaseConfig, error) {
+	dbConfig := DatabaseConfig{
+		DatabaseName: cfg.String("COUCHDB_DATABASE", ""),
+		CouchDBUrl:   cfg.String("COUCHDB_URL", "http://localhost:5984"),
+		Username:     cfg.String("COUCHDB_USER", "admin"),
+		Password:     cfg.Secret("COUCHDB_PASSWORD", "password"),
+	}
+	logrus.Debug("CouchDB configuration:\n" + cfg.Dump())
+	return &dbConfig, cfg.Err()
 }
 
 func (dc DatabaseConfig) DocumentURL(key string) string {
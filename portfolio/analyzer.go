@@ -0,0 +1,329 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+// Decimal places of the amounts written to portfolio_value.
+const (
+	moneyScale   = 4
+	sharesScale  = 6
+	percentScale = 4
+)
+
+// Analyzer calculates positions, gains and returns from the transactions and fund_history tables.
+type Analyzer struct {
+	pool         *pgxpool.Pool
+	transactions postgres.TransactionRepository
+	prices       postgres.FundHistoryRepository
+	values       postgres.PortfolioValueRepository
+
+	Method CostMethod
+	// Actions maps transaction types, in lower case, to their actions, DefaultActions by default.
+	Actions map[string]Action
+}
+
+// Returns are the returns of a position over a range of dates.
+type Returns struct {
+	// TimeWeighted is the return for the whole range, not annualized.
+	TimeWeighted float64
+	// MoneyWeighted is the annual internal rate of return, NaN when it can not be calculated.
+	MoneyWeighted float64
+	// Periods are the position's value on each day there is a price.
+	Periods []Period
+}
+
+// NewAnalyzer returns an analyzer that uses FIFO cost basis.
+func NewAnalyzer(pool *pgxpool.Pool) *Analyzer {
+	return &Analyzer{
+		pool:         pool,
+		transactions: postgres.NewTransactionRepository(pool),
+		prices:       postgres.NewFundHistoryRepository(pool),
+		values:       postgres.NewPortfolioValueRepository(pool),
+		Method:       FIFO,
+		Actions:      DefaultActions,
+	}
+}
+
+// day returns the start of t's date.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// endOfDay returns the last moment of t's date, so transactions at any time of the day are included.
+func endOfDay(t time.Time) time.Time {
+	return day(t).AddDate(0, 0, 1).Add(-time.Microsecond)
+}
+
+func (a *Analyzer) newBook() *Book {
+	book := NewBook(a.Method)
+	book.Actions = a.Actions
+	return book
+}
+
+// loadTransactions returns every transaction on or before the date, in the order they are applied.
+func (a *Analyzer) loadTransactions(ctx context.Context, asOf time.Time) ([]postgres.Transaction, error) {
+	transactions, err := a.transactions.ByDate(ctx, time.Time{}, endOfDay(asOf))
+	if err != nil {
+		return nil, err
+	}
+	SortTransactions(transactions)
+	return transactions, nil
+}
+
+// Positions returns the positions by account and symbol at the end of the date.
+func (a *Analyzer) Positions(ctx context.Context, asOf time.Time) ([]*Position, error) {
+	transactions, err := a.loadTransactions(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	book := a.newBook()
+	for _, t := range transactions {
+		book.Apply(t)
+	}
+	return book.Positions(), nil
+}
+
+// price returns the last close of the symbol on or before the date, nil when there is none.
+func (a *Analyzer) price(ctx context.Context, symbol string, asOf time.Time) (*postgres.FundHistory, *big.Rat, error) {
+	history, err := a.prices.Latest(ctx, symbol, asOf)
+	if errors.Is(err, postgres.ErrNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return history, postgres.NumericToRat(history.Close), nil
+}
+
+// totalReturn is the dollar return of a position valued at the price: its value, less the money invested, plus its
+// income.  It is nil when there is no price for shares that are held.
+func totalReturn(p *Position, price *big.Rat) *big.Rat {
+	value := new(big.Rat)
+	if p.Shares().Sign() != 0 {
+		if price == nil {
+			return nil
+		}
+		value = p.MarketValue(price)
+	}
+	value.Sub(value, p.Flows)
+	return value.Add(value, p.Income)
+}
+
+// Snapshot returns a portfolio_value row, dated asOf, for each symbol with shares held at the end of the date.
+// Positions are combined across accounts.
+func (a *Analyzer) Snapshot(ctx context.Context, asOf time.Time) ([]postgres.PortfolioValue, error) {
+	transactions, err := a.loadTransactions(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	// The positions a year ago are needed for the 12 month gain.
+	yearAgo := endOfDay(asOf.AddDate(-1, 0, 0))
+	book := a.newBook()
+	var then []*Position
+	for _, t := range transactions {
+		if then == nil && t.Date.Time.After(yearAgo) {
+			then = Total(book.Positions())
+		}
+		book.Apply(t)
+	}
+	if then == nil {
+		then = Total(book.Positions())
+	}
+	thenBySymbol := make(map[string]*Position)
+	for _, p := range then {
+		thenBySymbol[p.Symbol] = p
+	}
+
+	var (
+		held    []*Position
+		symbols []string
+	)
+	for _, p := range Total(book.Positions()) {
+		if p.Shares().Sign() != 0 {
+			held = append(held, p)
+			symbols = append(symbols, p.Symbol)
+		}
+	}
+	// The latest two prices give the quote and the day's change, the year old price is needed for the 12 month gain.
+	recent, err := a.prices.Recent(ctx, symbols, endOfDay(asOf), 2)
+	if err != nil {
+		return nil, err
+	}
+	yearOld, err := a.prices.Recent(ctx, symbols, yearAgo, 1)
+	if err != nil {
+		return nil, err
+	}
+	// closeOf returns the close of the nth most recent price, nil when there is none.
+	closeOf := func(prices map[string][]postgres.FundHistory, symbol string, n int) *big.Rat {
+		if len(prices[symbol]) <= n {
+			return nil
+		}
+		return postgres.NumericToRat(prices[symbol][n].Close)
+	}
+
+	var rows []postgres.PortfolioValue
+	for _, p := range held {
+		shares := p.Shares()
+
+		row := postgres.PortfolioValue{
+			Date:                day(asOf),
+			Name:                pgtype.Text{String: p.Security, Valid: p.Security != ""},
+			Symbol:              p.Symbol,
+			Shares:              postgres.RatToNumeric(shares, sharesScale),
+			CostBasis:           postgres.RatToNumeric(p.CostBasis(), moneyScale),
+			AverageCostPerShare: postgres.RatToNumeric(p.AverageCost(), moneyScale),
+		}
+
+		price := closeOf(recent, p.Symbol, 0)
+		if price == nil {
+			logrus.Warn("No price for ", p.Symbol, " on ", day(asOf).Format(time.DateOnly))
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Quote = postgres.RatToNumeric(price, moneyScale)
+		row.MarketValue = postgres.RatToNumeric(p.MarketValue(price), moneyScale)
+		gain := p.UnrealizedGain(price)
+		row.GainLoss = postgres.RatToNumeric(gain, moneyScale)
+		if cost := p.CostBasis(); cost.Sign() != 0 {
+			row.GainLossPct = postgres.RatToNumeric(percent(gain, cost), percentScale)
+		}
+
+		if previous := closeOf(recent, p.Symbol, 1); previous != nil {
+			change := new(big.Rat).Sub(price, previous)
+			row.PriceDayChange = postgres.RatToNumeric(change, moneyScale)
+			if previous.Sign() != 0 {
+				row.PriceDayChangePct = postgres.RatToNumeric(percent(change, previous), percentScale)
+			}
+		}
+
+		now := totalReturn(p, price)
+		before := new(big.Rat)
+		if old, ok := thenBySymbol[p.Symbol]; ok {
+			before = totalReturn(old, closeOf(yearOld, p.Symbol, 0))
+		}
+		if before != nil {
+			row.GainLoss12Month = postgres.RatToNumeric(new(big.Rat).Sub(now, before), moneyScale)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func percent(part, whole *big.Rat) *big.Rat {
+	p := new(big.Rat).Quo(part, whole)
+	return p.Mul(p, big.NewRat(100, 1))
+}
+
+// WriteSnapshot replaces the portfolio_value rows for the date with a new Snapshot in one transaction, so readers see
+// either the old snapshot or the new one.  It returns the number of rows written.
+func (a *Analyzer) WriteSnapshot(ctx context.Context, asOf time.Time) (int, error) {
+	rows, err := a.Snapshot(ctx, asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	err = postgres.WithTx(ctx, a.pool, postgres.TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM portfolio_value WHERE date = $1", day(asOf)); err != nil {
+			return err
+		}
+		values := a.values.InTx(tx)
+		for i := range rows {
+			if err := values.Upsert(ctx, &rows[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logrus.Error("WriteSnapshot:" + err.Error())
+		return 0, fmt.Errorf("portfolio snapshot %s: %w", day(asOf).Format(time.DateOnly), err)
+	}
+	logrus.Info("Wrote ", len(rows), " portfolio values for ", day(asOf).Format(time.DateOnly))
+	return len(rows), nil
+}
+
+// Returns calculates the time and money weighted returns of the symbol from the end of from to the end of to.  The
+// position is valued on each day fund_history has a price.  An empty account combines every account.
+func (a *Analyzer) Returns(ctx context.Context, account, symbol string, from, to time.Time) (*Returns, error) {
+	// Only the symbol's transactions are loaded, the whole ledger is only needed to value the whole portfolio.
+	all, err := a.transactions.BySymbol(ctx, symbol, time.Time{}, endOfDay(to))
+	if err != nil {
+		return nil, err
+	}
+	SortTransactions(all)
+	var transactions []postgres.Transaction
+	for _, t := range all {
+		if t.Symbol.String != symbol || account != "" && t.Account.String != account {
+			continue
+		}
+		t.Account = pgtype.Text{String: account, Valid: true}
+		transactions = append(transactions, t)
+	}
+
+	book := a.newBook()
+	next := 0
+	// apply adds the transactions up to the end of the date and returns the money they moved.
+	apply := func(date time.Time) (float64, float64) {
+		flow, income := new(big.Rat), new(big.Rat)
+		for ; next < len(transactions) && !transactions[next].Date.Time.After(endOfDay(date)); next++ {
+			effect := book.Apply(transactions[next])
+			flow.Add(flow, effect.Flow)
+			income.Add(income, effect.Income)
+		}
+		f, _ := flow.Float64()
+		i, _ := income.Float64()
+		return f, i
+	}
+	value := func(price *big.Rat) float64 {
+		p := book.Position(account, symbol)
+		if p == nil {
+			return 0
+		}
+		v, _ := p.MarketValue(price).Float64()
+		return v
+	}
+
+	apply(from)
+	_, startPrice, err := a.price(ctx, symbol, endOfDay(from))
+	if err != nil {
+		return nil, err
+	}
+	if startPrice == nil {
+		startPrice = new(big.Rat)
+	}
+	periods := []Period{{Date: day(from), Value: value(startPrice)}}
+
+	history, err := a.prices.BySymbol(ctx, symbol, day(from).AddDate(0, 0, 1), endOfDay(to))
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range history {
+		price := postgres.NumericToRat(h.Close)
+		if price == nil {
+			continue
+		}
+		flow, income := apply(h.Date)
+		periods = append(periods, Period{Date: day(h.Date), Value: value(price), Flow: flow, Income: income})
+	}
+
+	returns := &Returns{TimeWeighted: TimeWeightedReturn(periods), MoneyWeighted: math.NaN(), Periods: periods}
+	if mwr, err := MoneyWeightedReturn(CashFlows(periods)); err == nil {
+		returns.MoneyWeighted = mwr
+	}
+	return returns, nil
+}
@@ -0,0 +1,375 @@
+package portfolio
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+// Action is what a transaction does to a position.
+type Action int
+
+const (
+	// Ignore leaves the position alone, e.g. for cash transfers.
+	Ignore Action = iota
+	// Buy adds shares at their cost.
+	Buy
+	// Sell removes shares, realizing the gain or loss against their cost basis.
+	Sell
+	// Income is cash paid by the position, such as a dividend, interest or a capital gain distribution.
+	Income
+	// Reinvest is income used to buy more shares.  The shares are added at the reinvested amount.
+	Reinvest
+	// AddShares transfers shares in at their cost, zero when no amount is given.
+	AddShares
+	// RemoveShares transfers shares out at their cost basis without realizing a gain.
+	RemoveShares
+	// Split adds shares without changing the cost basis.  The transaction's shares are the shares added.
+	Split
+)
+
+// CostMethod is how the shares removed by a sale are matched to the shares bought.
+type CostMethod int
+
+const (
+	// FIFO sells the oldest shares first.
+	FIFO CostMethod = iota
+	// AverageCost sells shares at the average cost of all the shares held.
+	AverageCost
+)
+
+func (m CostMethod) String() string {
+	switch m {
+	case FIFO:
+		return "fifo"
+	case AverageCost:
+		return "average"
+	}
+	return fmt.Sprintf("CostMethod(%d)", int(m))
+}
+
+// DefaultActions maps the transaction types, in lower case, to their actions.  Types that are not listed are ignored.
+var DefaultActions = map[string]Action{
+	"buy":                                Buy,
+	"bought":                             Buy,
+	"buy shares":                         Buy,
+	"sell":                               Sell,
+	"sold":                               Sell,
+	"sell shares":                        Sell,
+	"dividend":                           Income,
+	"dividend income":                    Income,
+	"div income":                         Income,
+	"interest":                           Income,
+	"interest income":                    Income,
+	"short term cap gain dist":           Income,
+	"long term cap gain dist":            Income,
+	"short-term cap gain distribution":   Income,
+	"long-term cap gain distribution":    Income,
+	"reinvest dividend":                  Reinvest,
+	"reinvested dividend":                Reinvest,
+	"reinvest interest":                  Reinvest,
+	"reinvest short-term capital gain":   Reinvest,
+	"reinvest long-term capital gain":    Reinvest,
+	"reinvested short-term capital gain": Reinvest,
+	"reinvested long-term capital gain":  Reinvest,
+	"add shares":                         AddShares,
+	"added":                              AddShares,
+	"shares in":                          AddShares,
+	"remove shares":                      RemoveShares,
+	"removed":                            RemoveShares,
+	"shares out":                         RemoveShares,
+	"stock split":                        Split,
+	"split":                              Split,
+}
+
+// Lot is shares bought together and what they cost.
+type Lot struct {
+	Date   time.Time
+	Shares *big.Rat
+	Cost   *big.Rat
+}
+
+// Position is the holding of a symbol in an account.  Amounts are exact.
+type Position struct {
+	Account  string
+	Symbol   string
+	Security string
+	// Lots are the shares held, oldest first.  With AverageCost they are pooled into one lot.
+	Lots []Lot
+	// RealizedGain is the gain or loss realized by sales.
+	RealizedGain *big.Rat
+	// Income is the cash income paid, not counting reinvested income.
+	Income *big.Rat
+	// Flows is the money invested, cost of shares bought less the proceeds of shares sold.
+	Flows *big.Rat
+}
+
+func newPosition(account, symbol string) *Position {
+	return &Position{Account: account, Symbol: symbol, RealizedGain: new(big.Rat), Income: new(big.Rat), Flows: new(big.Rat)}
+}
+
+// Shares returns the number of shares held.
+func (p *Position) Shares() *big.Rat {
+	shares := new(big.Rat)
+	for _, lot := range p.Lots {
+		shares.Add(shares, lot.Shares)
+	}
+	return shares
+}
+
+// CostBasis returns the cost of the shares held.
+func (p *Position) CostBasis() *big.Rat {
+	cost := new(big.Rat)
+	for _, lot := range p.Lots {
+		cost.Add(cost, lot.Cost)
+	}
+	return cost
+}
+
+// AverageCost returns the cost basis per share, nil when no shares are held.
+func (p *Position) AverageCost() *big.Rat {
+	shares := p.Shares()
+	if shares.Sign() == 0 {
+		return nil
+	}
+	return new(big.Rat).Quo(p.CostBasis(), shares)
+}
+
+// MarketValue returns the value of the shares held at the price.
+func (p *Position) MarketValue(price *big.Rat) *big.Rat {
+	return new(big.Rat).Mul(p.Shares(), price)
+}
+
+// UnrealizedGain returns the gain or loss of the shares held at the price.
+func (p *Position) UnrealizedGain(price *big.Rat) *big.Rat {
+	return new(big.Rat).Sub(p.MarketValue(price), p.CostBasis())
+}
+
+// Effect is the money moved by a transaction, used to calculate returns.
+type Effect struct {
+	// Flow is the money invested in the position, negative when money is taken out.
+	Flow *big.Rat
+	// Income is the cash income paid by the position.
+	Income *big.Rat
+}
+
+type positionKey struct {
+	account string
+	symbol  string
+}
+
+// Book applies transactions, in date order, to positions.
+type Book struct {
+	Method CostMethod
+	// Actions maps transaction types, in lower case, to their actions, DefaultActions by default.
+	Actions map[string]Action
+
+	positions map[positionKey]*Position
+}
+
+// NewBook returns an empty book that uses the cost method.
+func NewBook(method CostMethod) *Book {
+	return &Book{Method: method, Actions: DefaultActions, positions: make(map[positionKey]*Position)}
+}
+
+// Action returns the action of the transaction type.
+func (b *Book) Action(transactionType string) Action {
+	return b.Actions[strings.ToLower(strings.TrimSpace(transactionType))]
+}
+
+// Position returns the position in the symbol held in the account, nil when there have been no transactions.
+func (b *Book) Position(account, symbol string) *Position {
+	return b.positions[positionKey{account, symbol}]
+}
+
+// Positions returns every position ordered by account and symbol, including those with no shares left.
+func (b *Book) Positions() []*Position {
+	positions := make([]*Position, 0, len(b.positions))
+	for _, p := range b.positions {
+		positions = append(positions, p)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Account != positions[j].Account {
+			return positions[i].Account < positions[j].Account
+		}
+		return positions[i].Symbol < positions[j].Symbol
+	})
+	return positions
+}
+
+// abs returns |n|, zero when n is NULL.
+func abs(n *big.Rat) *big.Rat {
+	if n == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Abs(n)
+}
+
+// firstNonZero returns the first amount that is not zero.
+func firstNonZero(amounts ...*big.Rat) *big.Rat {
+	for _, a := range amounts {
+		if a.Sign() != 0 {
+			return a
+		}
+	}
+	return new(big.Rat)
+}
+
+// Apply adds a transaction to its position.  Transactions without a symbol, or with a type that is ignored, return a
+// zero effect.  Share counts and amounts are used without their sign, the action decides the direction.
+func (b *Book) Apply(t postgres.Transaction) Effect {
+	effect := Effect{Flow: new(big.Rat), Income: new(big.Rat)}
+	action := b.Action(t.Type.String)
+	if !t.Symbol.Valid || t.Symbol.String == "" || action == Ignore {
+		return effect
+	}
+
+	key := positionKey{t.Account.String, t.Symbol.String}
+	p, ok := b.positions[key]
+	if !ok {
+		p = newPosition(key.account, key.symbol)
+		b.positions[key] = p
+	}
+	if t.Security.Valid {
+		p.Security = t.Security.String
+	}
+
+	shares := abs(postgres.NumericToRat(t.Shares))
+	investment := abs(postgres.NumericToRat(t.InvestmentAmount))
+	amount := abs(postgres.NumericToRat(t.Amount))
+
+	switch action {
+	case Buy, AddShares:
+		cost := firstNonZero(investment, amount)
+		b.add(p, t.Date.Time, shares, cost)
+		effect.Flow.Set(cost)
+	case Reinvest:
+		b.add(p, t.Date.Time, shares, firstNonZero(investment, amount))
+	case Sell:
+		proceeds := firstNonZero(amount, investment)
+		cost := b.remove(p, shares)
+		p.RealizedGain.Add(p.RealizedGain, new(big.Rat).Sub(proceeds, cost))
+		effect.Flow.Neg(proceeds)
+	case RemoveShares:
+		effect.Flow.Neg(b.remove(p, shares))
+	case Income:
+		effect.Income.Set(firstNonZero(amount, investment))
+		p.Income.Add(p.Income, effect.Income)
+	case Split:
+		split(p, shares)
+	}
+	p.Flows.Add(p.Flows, effect.Flow)
+	return effect
+}
+
+func (b *Book) add(p *Position, date time.Time, shares, cost *big.Rat) {
+	if b.Method == AverageCost && len(p.Lots) > 0 {
+		p.Lots[0].Shares.Add(p.Lots[0].Shares, shares)
+		p.Lots[0].Cost.Add(p.Lots[0].Cost, cost)
+		return
+	}
+	p.Lots = append(p.Lots, Lot{Date: date, Shares: new(big.Rat).Set(shares), Cost: new(big.Rat).Set(cost)})
+}
+
+// remove takes shares out of the position's lots, oldest first, and returns their cost.
+func (b *Book) remove(p *Position, shares *big.Rat) *big.Rat {
+	cost := new(big.Rat)
+	left := new(big.Rat).Set(shares)
+	for len(p.Lots) > 0 && left.Sign() > 0 {
+		lot := &p.Lots[0]
+		if lot.Shares.Cmp(left) <= 0 {
+			cost.Add(cost, lot.Cost)
+			left.Sub(left, lot.Shares)
+			p.Lots = p.Lots[1:]
+			continue
+		}
+
+		part := new(big.Rat).Mul(lot.Cost, new(big.Rat).Quo(left, lot.Shares))
+		cost.Add(cost, part)
+		lot.Cost.Sub(lot.Cost, part)
+		lot.Shares.Sub(lot.Shares, left)
+		left.SetInt64(0)
+	}
+
+	if left.Sign() > 0 {
+		logrus.Warn("Sold ", left.FloatString(6), " more shares of ", p.Symbol, " than held in ", p.Account)
+	}
+	return cost
+}
+
+// split spreads the added shares over the lots in proportion to their shares.
+func split(p *Position, added *big.Rat) {
+	held := p.Shares()
+	if held.Sign() == 0 {
+		return
+	}
+	ratio := new(big.Rat).Quo(new(big.Rat).Add(held, added), held)
+	for i := range p.Lots {
+		p.Lots[i].Shares.Mul(p.Lots[i].Shares, ratio)
+	}
+}
+
+// SortTransactions orders transactions by date, then id, the order they must be applied in.
+func SortTransactions(transactions []postgres.Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if !transactions[i].Date.Time.Equal(transactions[j].Date.Time) {
+			return transactions[i].Date.Time.Before(transactions[j].Date.Time)
+		}
+		return transactions[i].Id < transactions[j].Id
+	})
+}
+
+// BuildPositions applies the transactions dated on or before asOf and returns the positions by account and symbol.
+// Transactions without a date are skipped, they can not be put in order.
+func BuildPositions(transactions []postgres.Transaction, asOf time.Time, method CostMethod) []*Position {
+	sorted := append([]postgres.Transaction(nil), transactions...)
+	SortTransactions(sorted)
+
+	book := NewBook(method)
+	for _, t := range sorted {
+		if !t.Date.Valid {
+			continue
+		}
+		if t.Date.Time.After(asOf) {
+			break
+		}
+		book.Apply(t)
+	}
+	return book.Positions()
+}
+
+// Total combines the positions in each symbol across accounts.  The combined positions have no account.
+func Total(positions []*Position) []*Position {
+	bySymbol := make(map[string]*Position)
+	var symbols []string
+	for _, p := range positions {
+		total, ok := bySymbol[p.Symbol]
+		if !ok {
+			total = newPosition("", p.Symbol)
+			bySymbol[p.Symbol] = total
+			symbols = append(symbols, p.Symbol)
+		}
+		if total.Security == "" {
+			total.Security = p.Security
+		}
+		for _, lot := range p.Lots {
+			total.Lots = append(total.Lots, Lot{Date: lot.Date, Shares: new(big.Rat).Set(lot.Shares), Cost: new(big.Rat).Set(lot.Cost)})
+		}
+		total.RealizedGain.Add(total.RealizedGain, p.RealizedGain)
+		total.Income.Add(total.Income, p.Income)
+		total.Flows.Add(total.Flows, p.Flows)
+	}
+
+	sort.Strings(symbols)
+	totals := make([]*Position, len(symbols))
+	for i, s := range symbols {
+		totals[i] = bySymbol[s]
+		sort.SliceStable(totals[i].Lots, func(a, b int) bool { return totals[i].Lots[a].Date.Before(totals[i].Lots[b].Date) })
+	}
+	return totals
+}
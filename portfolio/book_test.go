@@ -0,0 +1,101 @@
+package portfolio_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kpearce2430/keputils/portfolio"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func numeric(s string) pgtype.Numeric {
+	var n pgtype.Numeric
+	if err := n.Scan(s); err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: true}
+}
+
+func rat(s string) *big.Rat {
+	r, _ := new(big.Rat).SetString(s)
+	return r
+}
+
+func transaction(id int64, date, typ, symbol, shares, amount string) postgres.Transaction {
+	d, _ := time.Parse(time.DateOnly, date)
+	return postgres.Transaction{Id: id, Date: pgtype.Timestamp{Time: d, Valid: true}, Type: text(typ), Symbol: text(symbol), Security: text(symbol + " Inc."),
+		Shares: numeric(shares), Amount: numeric(amount), Account: text("Brokerage")}
+}
+
+var transactions = []postgres.Transaction{
+	transaction(4, "2024-03-01", "Sold", "ABC", "-15", "300"),
+	transaction(1, "2024-01-02", "Bought", "ABC", "10", "-100"),
+	transaction(2, "2024-02-01", "Bought", "ABC", "10", "-140"),
+	transaction(3, "2024-02-15", "Dividend Income", "ABC", "0", "5"),
+	transaction(5, "2024-03-15", "Reinvest Dividend", "ABC", "0.5", "8"),
+	transaction(6, "2024-04-01", "Stock Split", "ABC", "5.5", "0"),
+	transaction(7, "2024-04-02", "Transfer", "", "0", "1000"),
+}
+
+func TestBuildPositions_FIFO(t *testing.T) {
+	positions := portfolio.BuildPositions(transactions, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), portfolio.FIFO)
+	if !assert.Len(t, positions, 1) {
+		return
+	}
+	p := positions[0]
+	assert.Equal(t, "Brokerage", p.Account)
+	assert.Equal(t, "ABC Inc.", p.Security)
+
+	// 15 sold: all of the first lot at 100 and half of the second at 70.
+	assert.Equal(t, rat("5.5").String(), p.Shares().String())
+	assert.Equal(t, rat("78").String(), p.CostBasis().String())
+	assert.Equal(t, rat("130").String(), p.RealizedGain.String())
+	assert.Equal(t, rat("5").String(), p.Income.String())
+	assert.Equal(t, rat("-60").String(), p.Flows.String())
+	assert.Equal(t, rat("110").String(), p.MarketValue(rat("20")).String())
+	assert.Equal(t, rat("32").String(), p.UnrealizedGain(rat("20")).String())
+
+	// The split doubles the shares without changing the cost.
+	positions = portfolio.BuildPositions(transactions, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), portfolio.FIFO)
+	assert.Equal(t, rat("11").String(), positions[0].Shares().String())
+	assert.Equal(t, rat("78").String(), positions[0].CostBasis().String())
+	assert.Len(t, positions[0].Lots, 2)
+}
+
+func TestBuildPositions_AverageCost(t *testing.T) {
+	positions := portfolio.BuildPositions(transactions, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), portfolio.AverageCost)
+	if !assert.Len(t, positions, 1) {
+		return
+	}
+	p := positions[0]
+
+	// 15 of 20 shares averaging 12 each are sold.
+	assert.Equal(t, rat("5").String(), p.Shares().String())
+	assert.Equal(t, rat("60").String(), p.CostBasis().String())
+	assert.Equal(t, rat("12").String(), p.AverageCost().String())
+	assert.Equal(t, rat("120").String(), p.RealizedGain.String())
+	assert.Len(t, p.Lots, 1)
+}
+
+func TestTotal(t *testing.T) {
+	other := transaction(8, "2024-01-10", "Buy", "ABC", "5", "-60")
+	other.Account = text("IRA")
+	positions := portfolio.BuildPositions(append([]postgres.Transaction{other}, transactions...),
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), portfolio.FIFO)
+	assert.Len(t, positions, 2)
+
+	totals := portfolio.Total(positions)
+	if !assert.Len(t, totals, 1) {
+		return
+	}
+	assert.Equal(t, "", totals[0].Account)
+	assert.Equal(t, rat("10").String(), totals[0].Shares().String())
+	assert.Equal(t, rat("130").String(), totals[0].CostBasis().String())
+}
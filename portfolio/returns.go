@@ -0,0 +1,135 @@
+package portfolio
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	irrTolerance     = 1e-10
+	irrMaxIterations = 100
+	daysPerYear      = 365.0
+)
+
+var (
+	errTooFewFlows = errors.New("need a positive and a negative cash flow")
+	errNoIRR       = errors.New("money weighted return did not converge")
+)
+
+// Period is the value of an investment at the end of a day and the money that moved during the day.
+type Period struct {
+	Date  time.Time
+	Value float64
+	// Flow is the money invested at the start of the day, negative when money is taken out.
+	Flow float64
+	// Income is the cash paid by the investment during the day.
+	Income float64
+}
+
+// CashFlow is money moving between the investor and the investment.  It is negative when the investor pays in and
+// positive when they receive money.
+type CashFlow struct {
+	Date   time.Time
+	Amount float64
+}
+
+// TimeWeightedReturn chains the daily returns of the periods, removing the effect of the money invested and taken
+// out.  The first period is the starting value, its flow and income are ignored.  Days that start with nothing
+// invested are skipped.
+func TimeWeightedReturn(periods []Period) float64 {
+	growth := 1.0
+	for i := 1; i < len(periods); i++ {
+		start := periods[i-1].Value + periods[i].Flow
+		if start <= 0 {
+			continue
+		}
+		growth *= (periods[i].Value + periods[i].Income) / start
+	}
+	return growth - 1
+}
+
+// CashFlows returns the investor's cash flows for the periods: buying the starting value, the money invested and taken
+// out, the income, and selling the ending value.
+func CashFlows(periods []Period) []CashFlow {
+	if len(periods) == 0 {
+		return nil
+	}
+
+	flows := []CashFlow{{Date: periods[0].Date, Amount: -periods[0].Value}}
+	for _, p := range periods[1:] {
+		if amount := p.Income - p.Flow; amount != 0 {
+			flows = append(flows, CashFlow{Date: p.Date, Amount: amount})
+		}
+	}
+	last := periods[len(periods)-1]
+	return append(flows, CashFlow{Date: last.Date, Amount: last.Value})
+}
+
+// netPresentValue discounts the flows to the first flow's date at the annual rate and returns the value and its
+// derivative.
+func netPresentValue(flows []CashFlow, rate float64) (float64, float64) {
+	var npv, derivative float64
+	for _, f := range flows {
+		years := f.Date.Sub(flows[0].Date).Hours() / 24 / daysPerYear
+		discount := math.Pow(1+rate, years)
+		npv += f.Amount / discount
+		derivative -= years * f.Amount / (discount * (1 + rate))
+	}
+	return npv, derivative
+}
+
+// MoneyWeightedReturn returns the annual internal rate of return of the cash flows, the rate that makes their net
+// present value zero, like a spreadsheet's XIRR.
+func MoneyWeightedReturn(flows []CashFlow) (float64, error) {
+	var positive, negative bool
+	for _, f := range flows {
+		positive = positive || f.Amount > 0
+		negative = negative || f.Amount < 0
+	}
+	if !positive || !negative {
+		return 0, errTooFewFlows
+	}
+
+	rate := 0.1
+	for i := 0; i < irrMaxIterations; i++ {
+		npv, derivative := netPresentValue(flows, rate)
+		if math.Abs(npv) < irrTolerance {
+			return rate, nil
+		}
+		if derivative == 0 {
+			break
+		}
+		next := rate - npv/derivative
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		if math.Abs(next-rate) < irrTolerance {
+			return next, nil
+		}
+		rate = next
+	}
+
+	// Newton's method can overshoot, so fall back to bisecting a range that brackets the root.
+	low, high := -0.999999, 1.0
+	npvLow, _ := netPresentValue(flows, low)
+	for npvHigh, _ := netPresentValue(flows, high); npvLow*npvHigh > 0; npvHigh, _ = netPresentValue(flows, high) {
+		if high > 1e6 {
+			return 0, errNoIRR
+		}
+		high *= 2
+	}
+	for i := 0; i < 1000; i++ {
+		mid := (low + high) / 2
+		npvMid, _ := netPresentValue(flows, mid)
+		if math.Abs(npvMid) < irrTolerance || high-low < irrTolerance {
+			return mid, nil
+		}
+		if npvLow*npvMid < 0 {
+			high = mid
+		} else {
+			low, npvLow = mid, npvMid
+		}
+	}
+	return 0, errNoIRR
+}
@@ -0,0 +1,80 @@
+package portfolio_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kpearce2430/keputils/portfolio"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse(time.DateOnly, s)
+	return d
+}
+
+func TestTimeWeightedReturn(t *testing.T) {
+	// 10% then, after doubling the investment, 10% again.
+	periods := []portfolio.Period{
+		{Date: date("2024-01-01"), Value: 1000},
+		{Date: date("2024-01-02"), Value: 1100},
+		{Date: date("2024-01-03"), Value: 2310, Flow: 1000},
+	}
+	assert.InDelta(t, 0.21, portfolio.TimeWeightedReturn(periods), 1e-12)
+
+	// Income counts as return, not as money taken out.
+	periods = []portfolio.Period{
+		{Date: date("2024-01-01"), Value: 1000},
+		{Date: date("2024-01-02"), Value: 990, Income: 10},
+	}
+	assert.InDelta(t, 0, portfolio.TimeWeightedReturn(periods), 1e-12)
+
+	// Nothing invested at the start.
+	periods = []portfolio.Period{
+		{Date: date("2024-01-01")},
+		{Date: date("2024-01-02"), Value: 105, Flow: 100},
+	}
+	assert.InDelta(t, 0.05, portfolio.TimeWeightedReturn(periods), 1e-12)
+}
+
+func TestMoneyWeightedReturn(t *testing.T) {
+	flows := []portfolio.CashFlow{
+		{Date: date("2023-01-01"), Amount: -1000},
+		{Date: date("2024-01-01"), Amount: 1100},
+	}
+	rate, err := portfolio.MoneyWeightedReturn(flows)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.1, rate, 1e-6)
+
+	// Matches the XIRR example in the spreadsheet documentation.
+	flows = []portfolio.CashFlow{
+		{Date: date("2008-01-01"), Amount: -10000},
+		{Date: date("2008-03-01"), Amount: 2750},
+		{Date: date("2008-10-30"), Amount: 4250},
+		{Date: date("2009-02-15"), Amount: 3250},
+		{Date: date("2009-04-01"), Amount: 2750},
+	}
+	rate, err = portfolio.MoneyWeightedReturn(flows)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.373362535, rate, 1e-6)
+
+	_, err = portfolio.MoneyWeightedReturn(flows[1:])
+	assert.NotNil(t, err)
+
+	periods := []portfolio.Period{
+		{Date: date("2023-01-01"), Value: 1000},
+		{Date: date("2023-07-01"), Value: 1500, Flow: 500, Income: 20},
+		{Date: date("2024-01-01"), Value: 1600},
+	}
+	cashFlows := portfolio.CashFlows(periods)
+	assert.Equal(t, []portfolio.CashFlow{
+		{Date: date("2023-01-01"), Amount: -1000},
+		{Date: date("2023-07-01"), Amount: -480},
+		{Date: date("2024-01-01"), Amount: 1600},
+	}, cashFlows)
+	rate, err = portfolio.MoneyWeightedReturn(cashFlows)
+	assert.Nil(t, err)
+	assert.False(t, math.IsNaN(rate))
+	assert.Greater(t, rate, 0.0)
+}
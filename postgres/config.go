@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// PostgresConfig holds the connection and pool settings.  When URL is set it is used for the connection, otherwise the
+// connection is built from the other connection fields.  Fields left empty, or zero, fall back to pgx's defaults,
+// which include the standard PGHOST, PGUSER, PGPASSWORD, etc. environment variables.
+type PostgresConfig struct {
+	URL      string `envconfig:"PG_DATABASE_URL"`
+	Host     string `envconfig:"PG_HOST"`
+	Port     uint16 `envconfig:"PG_PORT"`
+	User     string `envconfig:"PG_USER"`
+	Password string `envconfig:"PG_PASSWORD"`
+	DBName   string `envconfig:"PG_DBNAME"`
+	SSLMode  string `envconfig:"PG_SSLMODE"`
+
+	MaxConns          int32         `envconfig:"PG_MAX_CONNS"`
+	MinConns          int32         `envconfig:"PG_MIN_CONNS"`
+	MaxConnLifetime   time.Duration `envconfig:"PG_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime   time.Duration `envconfig:"PG_MAX_CONN_IDLE_TIME"`
+	HealthCheckPeriod time.Duration `envconfig:"PG_HEALTH_CHECK_PERIOD"`
+
+	// StatementTimeout aborts statements that run longer, zero means no limit.
+	StatementTimeout time.Duration `envconfig:"PG_STATEMENT_TIMEOUT"`
+	ApplicationName  string        `envconfig:"PG_APPLICATION_NAME"`
+}
+
+// NewPostgresConfig reads the configuration from the environment.  Each variable is read with the prefix first, e.g.
+// MYAPP_PG_HOST, then without it.  When prefix is empty the PREFIX environment variable is used.
+func NewPostgresConfig(prefix string) (*PostgresConfig, error) {
+	myprefix := prefix
+	if prefix == "" {
+		myprefix = utils.GetEnv("PREFIX", "")
+	}
+
+	var pgConfig PostgresConfig
+	err := envconfig.Process(myprefix, &pgConfig)
+	return &pgConfig, err
+}
+
+// quoteConnValue quotes a value for a keyword/value connection string.
+func quoteConnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// ConnString returns the connection string for the configuration.
+func (c PostgresConfig) ConnString() string {
+	if c.URL != "" {
+		return c.URL
+	}
+
+	var params []string
+	add := func(key, value string) {
+		if value != "" {
+			params = append(params, key+"="+quoteConnValue(value))
+		}
+	}
+	add("host", c.Host)
+	if c.Port != 0 {
+		add("port", strconv.Itoa(int(c.Port)))
+	}
+	add("user", c.User)
+	add("password", c.Password)
+	add("dbname", c.DBName)
+	add("sslmode", c.SSLMode)
+	return strings.Join(params, " ")
+}
+
+// PoolConfig returns the pgxpool configuration for the settings.
+func (c PostgresConfig) PoolConfig() (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(c.ConnString())
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	if c.MaxConns > 0 {
+		poolConfig.MaxConns = c.MaxConns
+	}
+	if c.MinConns > 0 {
+		poolConfig.MinConns = c.MinConns
+	}
+	if c.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = c.MaxConnLifetime
+	}
+	if c.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = c.MaxConnIdleTime
+	}
+	if c.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = c.HealthCheckPeriod
+	}
+
+	runtimeParams := poolConfig.ConnConfig.RuntimeParams
+	if c.StatementTimeout > 0 {
+		runtimeParams["statement_timeout"] = strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10)
+	}
+	if c.ApplicationName != "" {
+		runtimeParams["application_name"] = c.ApplicationName
+	}
+	return poolConfig, nil
+}
+
+// Connect opens a connection pool and pings the database before returning it.
+func Connect(ctx context.Context, cfg *PostgresConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := cfg.PoolConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	pgxConn, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	if err = pgxConn.Ping(ctx); err != nil {
+		pgxConn.Close()
+		logrus.Error("Connect:" + err.Error())
+		return nil, fmt.Errorf(errFormat, err)
+	}
+	return pgxConn, nil
+}
@@ -0,0 +1,62 @@
+package postgres_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPostgresConfig(t *testing.T) {
+	t.Setenv("PG_DATABASE_URL", "")
+	t.Setenv("PG_HOST", "db.example.com")
+	t.Setenv("TEST_PG_HOST", "test.example.com")
+	t.Setenv("TEST_PG_PORT", "6543")
+	t.Setenv("TEST_PG_USER", "stocks")
+	t.Setenv("TEST_PG_PASSWORD", "it's secret")
+	t.Setenv("TEST_PG_DBNAME", "stocks")
+	t.Setenv("TEST_PG_MAX_CONNS", "8")
+	t.Setenv("TEST_PG_MAX_CONN_LIFETIME", "30m")
+	t.Setenv("TEST_PG_STATEMENT_TIMEOUT", "15s")
+	t.Setenv("TEST_PG_APPLICATION_NAME", "keputils")
+
+	cfg, err := postgres.NewPostgresConfig("TEST")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "test.example.com", cfg.Host)
+	assert.Equal(t, uint16(6543), cfg.Port)
+	assert.Equal(t, int32(8), cfg.MaxConns)
+	assert.Equal(t, 30*time.Minute, cfg.MaxConnLifetime)
+
+	poolConfig, err := cfg.PoolConfig()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "test.example.com", poolConfig.ConnConfig.Host)
+	assert.Equal(t, uint16(6543), poolConfig.ConnConfig.Port)
+	assert.Equal(t, "it's secret", poolConfig.ConnConfig.Password)
+	assert.Equal(t, int32(8), poolConfig.MaxConns)
+	assert.Equal(t, 30*time.Minute, poolConfig.MaxConnLifetime)
+	assert.Equal(t, "15000", poolConfig.ConnConfig.RuntimeParams["statement_timeout"])
+	assert.Equal(t, "keputils", poolConfig.ConnConfig.RuntimeParams["application_name"])
+
+	// Without the prefix the unprefixed variable is used.
+	cfg, err = postgres.NewPostgresConfig("OTHER")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "db.example.com", cfg.Host)
+}
+
+func TestPostgresConfig_URL(t *testing.T) {
+	cfg := postgres.PostgresConfig{URL: "postgres://u:p@localhost:5433/stocks", Host: "ignored", MinConns: 2}
+	poolConfig, err := cfg.PoolConfig()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "localhost", poolConfig.ConnConfig.Host)
+	assert.Equal(t, "stocks", poolConfig.ConnConfig.Database)
+	assert.Equal(t, int32(2), poolConfig.MinConns)
+}
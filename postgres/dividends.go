@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// refreshDividendHistorySql fills dividend_history with the dividends received, from the transactions, and for
+// payments that have not been received yet, the income expected from the dividends announced for the shares held on
+// the ex-dividend date.  $1 are the dividend types, $2 and $3 the types adding and removing shares, $4 and $5 the
+// range of pay dates.
+const refreshDividendHistorySql = `
+WITH received AS (
+	SELECT symbol, EXTRACT(YEAR FROM date) AS year, EXTRACT(MONTH FROM date) AS month,
+		SUM(ABS(COALESCE(NULLIF(amount, 0), investment_amount))) AS amount
+	FROM transactions
+	WHERE symbol IS NOT NULL AND lower(type) = ANY($1) AND date >= $4 AND date < $5
+	GROUP BY 1, 2, 3
+), last_received AS (
+	SELECT symbol, MAX(date) AS date
+	FROM transactions
+	WHERE symbol IS NOT NULL AND lower(type) = ANY($1)
+	GROUP BY symbol
+), announced AS (
+	SELECT d.ticker AS symbol, EXTRACT(YEAR FROM d.pay_date) AS year, EXTRACT(MONTH FROM d.pay_date) AS month,
+		SUM(d.cash_amount * (
+			SELECT COALESCE(SUM(CASE WHEN lower(t.type) = ANY($2) THEN ABS(t.shares)
+				WHEN lower(t.type) = ANY($3) THEN -ABS(t.shares) ELSE 0 END), 0)
+			FROM transactions t
+			WHERE t.symbol = d.ticker AND t.date < d.ex_dividend_date)) AS amount
+	FROM dividends d
+	LEFT JOIN last_received l ON l.symbol = d.ticker
+	WHERE d.ex_dividend_date IS NOT NULL AND d.pay_date >= $4 AND d.pay_date < $5
+		AND (l.date IS NULL OR d.pay_date > l.date)
+	GROUP BY 1, 2, 3
+)
+INSERT INTO dividend_history (symbol, year, month, amount)
+SELECT symbol, year, month, amount FROM received
+UNION ALL
+SELECT a.symbol, a.year, a.month, a.amount FROM announced a
+WHERE a.amount > 0 AND NOT EXISTS (
+	SELECT 1 FROM received r WHERE r.symbol = a.symbol AND r.year = a.year AND r.month = a.month)
+ON CONFLICT (symbol, year, month) DO UPDATE SET amount = EXCLUDED.amount`
+
+// DividendHistoryJob aggregates the dividends in the transactions and dividends tables into monthly totals in
+// dividend_history.  Transaction types are matched ignoring case.
+type DividendHistoryJob struct {
+	pool *pgxpool.Pool
+	// DividendTypes are the transaction types of dividends received, including reinvested dividends.
+	DividendTypes []string
+	// SharesInTypes and SharesOutTypes are the transaction types that add and remove shares.  They give the shares
+	// held on an ex-dividend date, which the income expected from an announced dividend is based on.
+	SharesInTypes  []string
+	SharesOutTypes []string
+}
+
+// NewDividendHistoryJob returns a job that matches the common names of the transaction types.
+func NewDividendHistoryJob(pool *pgxpool.Pool) *DividendHistoryJob {
+	return &DividendHistoryJob{
+		pool:          pool,
+		DividendTypes: []string{"dividend", "dividend income", "div income", "reinvest dividend", "reinvested dividend"},
+		SharesInTypes: []string{"buy", "bought", "buy shares", "reinvest dividend", "reinvested dividend",
+			"reinvest interest", "reinvest short-term capital gain", "reinvest long-term capital gain",
+			"reinvested short-term capital gain", "reinvested long-term capital gain", "add shares", "added",
+			"shares in", "stock split", "split"},
+		SharesOutTypes: []string{"sell", "sold", "sell shares", "remove shares", "removed", "shares out"},
+	}
+}
+
+func lower(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(strings.TrimSpace(v))
+	}
+	return lowered
+}
+
+// Run replaces the dividend_history rows for fromYear through toYear in one transaction and returns the number of
+// rows written.  A month has the dividends received in it, or when none have been received yet, the income expected
+// from the dividends announced to be paid in it.
+func (j *DividendHistoryJob) Run(ctx context.Context, fromYear, toYear int) (int64, error) {
+	from := time.Date(fromYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(toYear+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var count int64
+	err := WithTx(ctx, j.pool, TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM dividend_history WHERE year >= $1 AND year <= $2", fromYear, toYear); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, refreshDividendHistorySql, lower(j.DividendTypes), lower(j.SharesInTypes),
+			lower(j.SharesOutTypes), from, to)
+		count = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		logrus.Error("DividendHistoryJob:" + err.Error())
+		return 0, fmt.Errorf(errFormat, err)
+	}
+	logrus.Info("Wrote ", count, " dividend history rows for ", fromYear, " to ", toYear)
+	return count, nil
+}
+
+// DividendIncome is a symbol's dividend income over the twelve months ending with a date's month.
+type DividendIncome struct {
+	Symbol string         `db:"symbol"`
+	Income pgtype.Numeric `db:"income"`
+	// CostBasis is from the symbol's latest portfolio_value row on or before the date, NULL when there is none.
+	CostBasis pgtype.Numeric `db:"costbasis"`
+	// YieldOnCost is the income as a percent of the cost basis.
+	YieldOnCost pgtype.Numeric `db:"yield_on_cost"`
+}
+
+// monthIndex numbers months so that consecutive months differ by one.
+func monthIndex(t time.Time) int {
+	return t.Year()*12 + int(t.Month())
+}
+
+// TrailingTwelveMonths returns the dividend income of each symbol, or only of the symbol when it is not empty, over the
+// twelve months ending with the month of asOf, and its yield on cost.
+func (r DividendHistoryRepository) TrailingTwelveMonths(ctx context.Context, symbol string, asOf time.Time) ([]DividendIncome, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT h.symbol, SUM(h.amount) AS income, pv.costbasis,
+	CASE WHEN pv.costbasis > 0 THEN ROUND(SUM(h.amount) / pv.costbasis * 100, 4) END AS yield_on_cost
+FROM dividend_history h
+LEFT JOIN LATERAL (
+	SELECT costbasis FROM portfolio_value p WHERE p.symbol = h.symbol AND p.date < $2 ORDER BY p.date DESC LIMIT 1
+) pv ON true
+WHERE h.year * 12 + h.month BETWEEN $1 - 11 AND $1 AND ($3 = '' OR h.symbol = $3)
+GROUP BY h.symbol, pv.costbasis
+ORDER BY h.symbol`, monthIndex(asOf), time.Date(asOf.Year(), asOf.Month(), asOf.Day()+1, 0, 0, 0, 0, time.UTC), symbol)
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	income, err := pgx.CollectRows(rows, pgx.RowToStructByName[DividendIncome])
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+	return income, nil
+}
+
+// YieldOnCost returns the symbol's trailing twelve month dividend income as a percent of its cost basis, NULL when
+// there is no income or cost basis.
+func (r DividendHistoryRepository) YieldOnCost(ctx context.Context, symbol string, asOf time.Time) (pgtype.Numeric, error) {
+	income, err := r.TrailingTwelveMonths(ctx, symbol, asOf)
+	if err != nil || len(income) == 0 {
+		return pgtype.Numeric{}, err
+	}
+	return income[0].YieldOnCost, nil
+}
@@ -0,0 +1,92 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDividendHistoryJob(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	err := postgres.TruncateTables(ctx, pgxConn, []string{"transactions", "dividends", "dividend_history", "portfolio_value"},
+		postgres.TruncateOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	transactions := []postgres.Transaction{
+		{Id: 1, Date: pgtype.Timestamp{Time: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Valid: true}, Type: pgtype.Text{String: "Bought", Valid: true},
+			Symbol: pgtype.Text{String: "HD", Valid: true}, Shares: numeric(t, "100"), Amount: numeric(t, "-1000")},
+		{Id: 2, Date: pgtype.Timestamp{Time: time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC), Valid: true}, Type: pgtype.Text{String: "Dividend Income", Valid: true},
+			Symbol: pgtype.Text{String: "HD", Valid: true}, Amount: numeric(t, "50")},
+		{Id: 3, Date: pgtype.Timestamp{Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Valid: true}, Type: pgtype.Text{String: "Sold", Valid: true},
+			Symbol: pgtype.Text{String: "HD", Valid: true}, Shares: numeric(t, "-25"), Amount: numeric(t, "300")},
+	}
+	if _, err = postgres.NewTransactionRepository(pgxConn).BatchInsert(ctx, transactions); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	timestamp := func(year int, month time.Month, day int) pgtype.Timestamp {
+		return pgtype.Timestamp{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+	dividends := []postgres.Dividend{
+		// Already received in March.
+		{Ticker: "HD", CashAmount: numeric(t, "0.5"), DeclarationDate: time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC),
+			ExDividendDate: timestamp(2024, 3, 7), PayDate: timestamp(2024, 3, 21)},
+		// Expected for the 75 shares held.
+		{Ticker: "HD", CashAmount: numeric(t, "0.6"), DeclarationDate: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC),
+			ExDividendDate: timestamp(2024, 5, 30), PayDate: timestamp(2024, 6, 13)},
+	}
+	if _, err = postgres.NewDividendRepository(pgxConn).BatchInsert(ctx, dividends); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	count, err := postgres.NewDividendHistoryJob(pgxConn).Run(ctx, 2024, 2024)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	history := postgres.NewDividendHistoryRepository(pgxConn)
+	rows, err := history.BySymbol(ctx, "HD", 2024, 2024)
+	assert.Nil(t, err)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, 3, rows[0].Month)
+		assert.Equal(t, 6, rows[1].Month)
+		amount, _ := rows[1].Amount.Float64Value()
+		assert.Equal(t, 45.0, amount.Float64)
+	}
+
+	// Running again replaces the rows.
+	count, err = postgres.NewDividendHistoryJob(pgxConn).Run(ctx, 2024, 2024)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	value := postgres.PortfolioValue{Date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Symbol: "HD",
+		CostBasis: numeric(t, "750")}
+	assert.Nil(t, postgres.NewPortfolioValueRepository(pgxConn).Insert(ctx, &value))
+
+	income, err := history.TrailingTwelveMonths(ctx, "", time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	if assert.Len(t, income, 1) {
+		ttm, _ := income[0].Income.Float64Value()
+		assert.Equal(t, 95.0, ttm.Float64)
+	}
+
+	yield, err := history.YieldOnCost(ctx, "HD", time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	yieldValue, _ := yield.Value()
+	assert.Equal(t, "12.6667", yieldValue)
+
+	// March's dividend is more than twelve months before.
+	income, err = history.TrailingTwelveMonths(ctx, "HD", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	if assert.Len(t, income, 1) {
+		ttm, _ := income[0].Income.Float64Value()
+		assert.Equal(t, 45.0, ttm.Float64)
+	}
+}
@@ -0,0 +1,527 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/parquet-go/parquet-go"
+	"github.com/segmentio/encoding/json"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportFormat is the output format of an export.
+type ExportFormat int
+
+const (
+	ExportCSV ExportFormat = iota
+	ExportJSONLines
+	ExportParquet
+)
+
+const (
+	// parquetRowGroupRows is how many rows are buffered before they are written to the parquet file.
+	parquetRowGroupRows = 10000
+
+	timestampLayout = "2006-01-02T15:04:05.999999"
+	// unixEpochJDN is the Julian Day Number of 1970-01-01, day 0 of a parquet DATE.
+	unixEpochJDN = 2440588
+)
+
+var (
+	errUnknownExportFormat = errors.New("unknown export format")
+	errDuplicateColumn     = errors.New("duplicate column name")
+	errMultipleStatements  = errors.New("export query must be a single statement")
+)
+
+func (f ExportFormat) String() string {
+	switch f {
+	case ExportCSV:
+		return "csv"
+	case ExportJSONLines:
+		return "jsonl"
+	case ExportParquet:
+		return "parquet"
+	}
+	return fmt.Sprintf("ExportFormat(%d)", int(f))
+}
+
+// ExportTable writes every row of the table to w in the format.
+func ExportTable(ctx context.Context, pool *pgxpool.Pool, table string, format ExportFormat, w io.Writer) (int64, error) {
+	ident, err := ParseIdentifier(table)
+	if err != nil {
+		return 0, err
+	}
+	return ExportQuery(ctx, pool, "SELECT * FROM "+ident.Sanitize(), nil, format, w)
+}
+
+// ExportQuery streams the results of the query to w and returns the number of rows written.
+//
+// CSV has a header row and uses COPY TO STDOUT when there are no arguments, so values are formatted exactly as
+// Postgres prints them.  JSON Lines writes an object per row with NUMERIC values as exact JSON numbers and timestamps in
+// ISO 8601.  Parquet maps NUMERIC(p,s) to DECIMAL and unconstrained NUMERIC to strings, so no value passes through a
+// float;  cast in the query, e.g. amount::numeric(18,2), to get a DECIMAL column.
+//
+// The query must be a single statement.  It may end with a ';' and comments, which are removed.
+func ExportQuery(ctx context.Context, pool *pgxpool.Pool, sql string, args []any, format ExportFormat, w io.Writer) (int64, error) {
+	sql, err := singleStatement(sql)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	switch format {
+	case ExportCSV:
+		if len(args) == 0 {
+			count, err = copyToCSV(ctx, pool, sql, w)
+		} else {
+			// Text results are written as is, so they match COPY's output.
+			count, err = exportRows(ctx, pool, sql, args, true, w, writeCSV)
+		}
+	case ExportJSONLines:
+		count, err = exportRows(ctx, pool, sql, args, false, w, writeJSONLines)
+	case ExportParquet:
+		count, err = exportRows(ctx, pool, sql, args, false, w, writeParquet)
+	default:
+		err = fmt.Errorf("%w: %d", errUnknownExportFormat, int(format))
+	}
+	if err != nil {
+		logrus.Error("ExportQuery:" + err.Error())
+		return count, fmt.Errorf(errFormat, err)
+	}
+	logrus.Info("Exported ", count, " rows as ", format)
+	return count, nil
+}
+
+// singleStatement returns the statement in sql without a terminating ';' or the comments after it, since COPY wraps
+// the query in parentheses.  Quoted strings, identifiers and comments are skipped, so a ';' in them is not the end of
+// the statement.  It is an error for anything but comments to follow the ';'.
+func singleStatement(sql string) (string, error) {
+	end := 0
+	terminated := false
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			if n := strings.IndexByte(sql[i:], '\n'); n >= 0 {
+				i += n + 1
+			} else {
+				i = len(sql)
+			}
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = skipBlockComment(sql, i)
+			continue
+		case c == ';':
+			terminated = true
+			i++
+			continue
+		}
+
+		if terminated {
+			return "", errMultipleStatements
+		}
+		switch {
+		case c == '\'' || c == '"':
+			// E'...' strings may escape the quote with a backslash.
+			escapes := c == '\'' && i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e')
+			i = skipQuoted(sql, i, c, escapes)
+		case c == '$':
+			i = skipDollarQuoted(sql, i)
+		default:
+			i++
+		}
+		end = i
+	}
+	return sql[:end], nil
+}
+
+// skipQuoted returns the index after the quoted text starting at i.  A doubled quote is part of the text.
+func skipQuoted(sql string, i int, quote byte, escapes bool) int {
+	for i++; i < len(sql); i++ {
+		switch {
+		case escapes && sql[i] == '\\':
+			i++
+		case sql[i] == quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// skipBlockComment returns the index after the comment starting at i.  Block comments nest.
+func skipBlockComment(sql string, i int) int {
+	depth := 0
+	for i < len(sql) {
+		switch {
+		case strings.HasPrefix(sql[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(sql[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(sql)
+}
+
+// skipDollarQuoted returns the index after a $tag$...$tag$ string starting at i, or after the '$' when it does not
+// start one, as in a $1 parameter.
+func skipDollarQuoted(sql string, i int) int {
+	n := strings.IndexByte(sql[i+1:], '$')
+	// A '$' inside an identifier, like foo$bar, does not start a string.
+	if n < 0 || i > 0 && isIdentifierByte(sql[i-1]) {
+		return i + 1
+	}
+	tag := sql[i : i+n+2]
+	for j, r := range tag[1 : len(tag)-1] {
+		if !(r == '_' || unicode.IsLetter(r) || j > 0 && unicode.IsDigit(r)) {
+			return i + 1
+		}
+	}
+	if closing := strings.Index(sql[i+len(tag):], tag); closing >= 0 {
+		return i + len(tag) + closing + len(tag)
+	}
+	return len(sql)
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func copyToCSV(ctx context.Context, pool *pgxpool.Pool, sql string, w io.Writer) (int64, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Conn().PgConn().CopyTo(ctx, w, fmt.Sprintf("COPY (%s) TO STDOUT WITH (FORMAT csv, HEADER true)", sql))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// rowWriter writes the rows of a query to w.
+type rowWriter func(rows pgx.Rows, w io.Writer) (int64, error)
+
+// exportRows runs the query and writes its rows with write.  Text results are requested when text is set.
+func exportRows(ctx context.Context, pool *pgxpool.Pool, sql string, args []any, text bool, w io.Writer, write rowWriter) (int64, error) {
+	var queryArgs []any
+	if text {
+		queryArgs = append(queryArgs, pgx.QueryResultFormats{pgx.TextFormatCode})
+	}
+	queryArgs = append(queryArgs, args...)
+
+	rows, err := pool.Query(ctx, sql, queryArgs...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count, err := write(rows, w)
+	if err != nil {
+		return count, err
+	}
+	return count, rows.Err()
+}
+
+func columnNames(fields []pgconn.FieldDescription) []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func writeCSV(rows pgx.Rows, w io.Writer) (int64, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columnNames(rows.FieldDescriptions())); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	record := make([]string, len(rows.FieldDescriptions()))
+	for rows.Next() {
+		for i, raw := range rows.RawValues() {
+			record[i] = string(raw)
+		}
+		if err := writer.Write(record); err != nil {
+			return count, err
+		}
+		count++
+	}
+	writer.Flush()
+	return count, writer.Error()
+}
+
+// jsonValue converts a row value to one that marshals without losing precision.
+func jsonValue(field pgconn.FieldDescription, value any) (any, error) {
+	switch v := value.(type) {
+	case pgtype.Numeric:
+		text, err := v.Value()
+		if err != nil || text == nil {
+			return text, err
+		}
+		// NaN and infinity are not JSON numbers.
+		if v.NaN || v.InfinityModifier != pgtype.Finite {
+			return text, nil
+		}
+		return json.Number(text.(string)), nil
+	case time.Time:
+		switch field.DataTypeOID {
+		case pgtype.DateOID:
+			return v.Format(time.DateOnly), nil
+		case pgtype.TimestampOID:
+			return v.Format(timestampLayout), nil
+		}
+		return v.Format(time.RFC3339Nano), nil
+	}
+	return value, nil
+}
+
+func writeJSONLines(rows pgx.Rows, w io.Writer) (int64, error) {
+	fields := rows.FieldDescriptions()
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+
+	var count int64
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return count, err
+		}
+
+		// Keys are written in column order.
+		object := make(jsonObject, len(fields))
+		for i, f := range fields {
+			if object[i].value, err = jsonValue(f, values[i]); err != nil {
+				return count, err
+			}
+			object[i].key = f.Name
+		}
+		if err = encoder.Encode(object); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, bw.Flush()
+}
+
+// jsonObject marshals its fields in order.
+type jsonObject []struct {
+	key   string
+	value any
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, f := range o {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(append(buf, key...), ':'), value...)
+	}
+	return append(buf, '}'), nil
+}
+
+// parquetColumn converts a row value into a parquet value for one column.
+type parquetColumn struct {
+	node    parquet.Node
+	convert func(any) (parquet.Value, error)
+}
+
+func parquetColumnFor(field pgconn.FieldDescription) parquetColumn {
+	switch field.DataTypeOID {
+	case pgtype.BoolOID:
+		return parquetColumn{parquet.Leaf(parquet.BooleanType), func(v any) (parquet.Value, error) {
+			return parquet.BooleanValue(v.(bool)), nil
+		}}
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID:
+		return parquetColumn{parquet.Int(64), func(v any) (parquet.Value, error) {
+			switch i := v.(type) {
+			case int16:
+				return parquet.Int64Value(int64(i)), nil
+			case int32:
+				return parquet.Int64Value(int64(i)), nil
+			}
+			return parquet.Int64Value(v.(int64)), nil
+		}}
+	case pgtype.Float4OID, pgtype.Float8OID:
+		return parquetColumn{parquet.Leaf(parquet.DoubleType), func(v any) (parquet.Value, error) {
+			if f, ok := v.(float32); ok {
+				return parquet.DoubleValue(float64(f)), nil
+			}
+			return parquet.DoubleValue(v.(float64)), nil
+		}}
+	case pgtype.DateOID:
+		return parquetColumn{parquet.Date(), func(v any) (parquet.Value, error) {
+			// Days since 1970-01-01, counted down for earlier dates rather than truncated toward it.
+			return parquet.Int32Value(int32(utils.JulianDayNumber(v.(time.Time)) - unixEpochJDN)), nil
+		}}
+	case pgtype.TimestampOID, pgtype.TimestamptzOID:
+		adjusted := field.DataTypeOID == pgtype.TimestamptzOID
+		return parquetColumn{parquet.TimestampAdjusted(parquet.Microsecond, adjusted), func(v any) (parquet.Value, error) {
+			return parquet.Int64Value(v.(time.Time).UnixMicro()), nil
+		}}
+	case pgtype.NumericOID:
+		// The type modifier of NUMERIC(p,s) is ((p << 16) | s) + 4, or -1 when unconstrained.
+		if field.TypeModifier >= 4 {
+			precision := int((field.TypeModifier - 4) >> 16)
+			scale := int((field.TypeModifier - 4) & 0xffff)
+			if precision <= 18 {
+				return parquetColumn{parquet.Decimal(scale, precision, parquet.Int64Type), func(v any) (parquet.Value, error) {
+					unscaled, err := unscaledNumeric(v.(pgtype.Numeric), scale)
+					if err != nil {
+						return parquet.Value{}, err
+					}
+					return parquet.Int64Value(unscaled.Int64()), nil
+				}}
+			}
+		}
+		return parquetColumn{parquet.String(), func(v any) (parquet.Value, error) {
+			text, err := v.(pgtype.Numeric).Value()
+			if err != nil {
+				return parquet.Value{}, err
+			}
+			return parquet.ByteArrayValue([]byte(text.(string))), nil
+		}}
+	case pgtype.ByteaOID:
+		return parquetColumn{parquet.Leaf(parquet.ByteArrayType), func(v any) (parquet.Value, error) {
+			return parquet.ByteArrayValue(v.([]byte)), nil
+		}}
+	}
+
+	return parquetColumn{parquet.String(), func(v any) (parquet.Value, error) {
+		if s, ok := v.(string); ok {
+			return parquet.ByteArrayValue([]byte(s)), nil
+		}
+		return parquet.ByteArrayValue([]byte(fmt.Sprint(v))), nil
+	}}
+}
+
+// unscaledNumeric returns the numeric as an integer count of 10^-scale.
+func unscaledNumeric(n pgtype.Numeric, scale int) (*big.Int, error) {
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return nil, fmt.Errorf("numeric %v can not be stored as a decimal", n)
+	}
+	unscaled := new(big.Int).Set(n.Int)
+	shift := int64(n.Exp) + int64(scale)
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(abs64(shift)), nil)
+	if shift >= 0 {
+		return unscaled.Mul(unscaled, pow), nil
+	}
+	return unscaled.Quo(unscaled, pow), nil
+}
+
+func abs64(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func writeParquet(rows pgx.Rows, w io.Writer) (int64, error) {
+	fields := rows.FieldDescriptions()
+	group := make(parquet.Group, len(fields))
+	columns := make(map[string]parquetColumn, len(fields))
+	for _, f := range fields {
+		if _, ok := group[f.Name]; ok {
+			return 0, fmt.Errorf("%w: %s", errDuplicateColumn, f.Name)
+		}
+		column := parquetColumnFor(f)
+		columns[f.Name] = column
+		group[f.Name] = parquet.Optional(column.node)
+	}
+
+	// A parquet group orders its columns by name, so find where each result column lands.
+	schema := parquet.NewSchema("export", group)
+	leafIndex := make([]int, len(fields))
+	for i, f := range fields {
+		for j, path := range schema.Columns() {
+			if path[0] == f.Name {
+				leafIndex[i] = j
+			}
+		}
+	}
+
+	writer := parquet.NewWriter(w, schema)
+	var (
+		count int64
+		batch []parquet.Row
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := writer.WriteRows(batch)
+		batch = batch[:0]
+		return err
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return count, err
+		}
+
+		row := make(parquet.Row, len(fields))
+		for i, f := range fields {
+			if values[i] == nil {
+				row[leafIndex[i]] = parquet.NullValue().Level(0, 0, leafIndex[i])
+				continue
+			}
+			value, err := columns[f.Name].convert(values[i])
+			if err != nil {
+				return count, fmt.Errorf("column %s: %w", f.Name, err)
+			}
+			row[leafIndex[i]] = value.Level(0, 1, leafIndex[i])
+		}
+
+		batch = append(batch, row)
+		count++
+		if len(batch) == parquetRowGroupRows {
+			if err = flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return count, err
+	}
+	return count, writer.Close()
+}
@@ -0,0 +1,116 @@
+package postgres_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const exportQuery = `
+SELECT symbol, date::date, amount::numeric(12,4), price
+FROM (VALUES ('AAPL', '2024-06-10', 1234.5678, 0.1::numeric), ('MSFT', '2024-06-11', NULL, 12345678901234567890.01))
+AS t(symbol, date, amount, price)
+ORDER BY symbol`
+
+func TestExportQuery(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	var buf bytes.Buffer
+	count, err := postgres.ExportQuery(ctx, pgxConn, exportQuery, nil, postgres.ExportCSV, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+	csvCopy := "symbol,date,amount,price\nAAPL,2024-06-10,1234.5678,0.1\nMSFT,2024-06-11,,12345678901234567890.01\n"
+	assert.Equal(t, csvCopy, buf.String())
+
+	// COPY can not copy a query ending in ';' or a comment, they are removed.
+	for _, end := range []string{";\n", "; -- done", "/* done; */", ";\n/* done */\n-- really;\n"} {
+		buf.Reset()
+		_, err = postgres.ExportQuery(ctx, pgxConn, exportQuery+end, nil, postgres.ExportCSV, &buf)
+		assert.Nil(t, err, end)
+		assert.Equal(t, csvCopy, buf.String(), end)
+	}
+	buf.Reset()
+	_, err = postgres.ExportQuery(ctx, pgxConn, `SELECT ';' AS "a;b", $$x;$$ AS c; -- done`, nil, postgres.ExportCSV, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "a;b,c\n;,x;\n", buf.String())
+
+	// With arguments the rows are not copied, but the output is the same.
+	buf.Reset()
+	_, err = postgres.ExportQuery(ctx, pgxConn, "SELECT * FROM ("+exportQuery+") q WHERE symbol <> $1", []any{"IBM"},
+		postgres.ExportCSV, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, csvCopy, buf.String())
+
+	buf.Reset()
+	_, err = postgres.ExportQuery(ctx, pgxConn, exportQuery, nil, postgres.ExportJSONLines, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"symbol":"AAPL","date":"2024-06-10","amount":1234.5678,"price":0.1}
+{"symbol":"MSFT","date":"2024-06-11","amount":null,"price":12345678901234567890.01}
+`, buf.String())
+
+	buf.Reset()
+	count, err = postgres.ExportQuery(ctx, pgxConn, exportQuery, nil, postgres.ExportParquet, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, int64(2), file.NumRows())
+
+	schema := file.Schema()
+	amount, ok := schema.Lookup("amount")
+	assert.True(t, ok)
+	assert.Contains(t, amount.Node.Type().LogicalType().String(), "DECIMAL")
+	price, ok := schema.Lookup("price")
+	assert.True(t, ok)
+	assert.Contains(t, price.Node.Type().LogicalType().String(), "STRING")
+
+	rows := make([]parquet.Row, 2)
+	reader := parquet.NewReader(file)
+	n, _ := reader.ReadRows(rows)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(12345678), rows[0][amount.ColumnIndex].Int64())
+	assert.True(t, rows[1][amount.ColumnIndex].IsNull())
+	assert.Equal(t, "12345678901234567890.01", rows[1][price.ColumnIndex].String())
+}
+
+func TestExportQuery_ParquetDates(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	var buf bytes.Buffer
+	_, err := postgres.ExportQuery(ctx, pgxConn,
+		"SELECT d::date AS date FROM (VALUES ('1969-12-31'), ('1970-01-01'), ('1929-10-29')) AS t(d);", nil,
+		postgres.ExportParquet, &buf)
+	assert.Nil(t, err)
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	rows := make([]parquet.Row, 3)
+	n, _ := parquet.NewReader(file).ReadRows(rows)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, int32(-1), rows[0][0].Int32())
+	assert.Equal(t, int32(0), rows[1][0].Int32())
+	assert.Equal(t, int32(-14674), rows[2][0].Int32())
+}
+
+func TestExportQuery_MultipleStatements(t *testing.T) {
+	for _, sql := range []string{"SELECT 1; SELECT 2", "SELECT 1;; DROP TABLE t", "SELECT 1 /* x */ ; SELECT 2 -- y"} {
+		_, err := postgres.ExportQuery(context.Background(), nil, sql, nil, postgres.ExportCSV, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "single statement", sql)
+	}
+}
+
+func TestExportQuery_UnknownFormat(t *testing.T) {
+	_, err := postgres.ExportQuery(context.Background(), nil, "SELECT 1", nil, postgres.ExportFormat(99), &bytes.Buffer{})
+	assert.NotNil(t, err)
+}
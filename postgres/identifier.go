@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+var errBadIdentifier = errors.New("invalid table name")
+
+// TruncateOptions are the options of TruncateTables.
+type TruncateOptions struct {
+	// Cascade also truncates the tables with foreign keys to the tables.
+	Cascade bool
+	// RestartIdentity resets the sequences owned by the tables' columns.
+	RestartIdentity bool
+}
+
+// ParseIdentifier splits a table name, either table or schema.table, into an identifier that is safe to put in SQL.
+// Each part is used exactly as written, including its case.  Double quote a part that contains a dot, doubling any
+// quotes inside it, e.g. "my.schema"."Fund ""A""".
+func ParseIdentifier(name string) (pgx.Identifier, error) {
+	var (
+		ident     pgx.Identifier
+		part      strings.Builder
+		quoted    bool
+		wasQuoted bool
+	)
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case quoted && c == '"' && i+1 < len(name) && name[i+1] == '"':
+			part.WriteByte('"')
+			i++
+		case c == '"' && (quoted || part.Len() == 0 && !wasQuoted):
+			quoted = !quoted
+			wasQuoted = true
+		case c == '.' && !quoted:
+			ident = append(ident, part.String())
+			part.Reset()
+			wasQuoted = false
+		case c == '"' || wasQuoted && !quoted:
+			return nil, fmt.Errorf("%w: %q", errBadIdentifier, name)
+		default:
+			part.WriteByte(c)
+		}
+	}
+	ident = append(ident, part.String())
+
+	if quoted || len(ident) > 2 {
+		return nil, fmt.Errorf("%w: %q", errBadIdentifier, name)
+	}
+	for _, p := range ident {
+		if p == "" {
+			return nil, fmt.Errorf("%w: %q", errBadIdentifier, name)
+		}
+	}
+	return ident, nil
+}
+
+// schemaAndTable returns the schema, empty when the identifier does not name one, and the table.
+func schemaAndTable(ident pgx.Identifier) (string, string) {
+	if len(ident) == 1 {
+		return "", ident[0]
+	}
+	return ident[0], ident[1]
+}
+
+// TruncateTable will truncate any postgres table.
+func TruncateTable(pgxConn *pgxpool.Pool, table string) error {
+	ident, err := ParseIdentifier(table)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err = pgxConn.QueryRow(context.Background(), "SELECT COUNT(*) FROM "+ident.Sanitize()).Scan(&count); err != nil {
+		return err
+	}
+	logrus.Info("Found ", count, " Rows")
+	if count == 0 {
+		return nil
+	}
+
+	if _, err = pgxConn.Exec(context.Background(), "TRUNCATE "+ident.Sanitize()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TruncateTables truncates all the tables in one transaction, so either every table is emptied or none are.
+func TruncateTables(ctx context.Context, pgxConn *pgxpool.Pool, tables []string, opts TruncateOptions) error {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		ident, err := ParseIdentifier(table)
+		if err != nil {
+			return err
+		}
+		names[i] = ident.Sanitize()
+	}
+
+	sql := "TRUNCATE " + strings.Join(names, ", ")
+	if opts.RestartIdentity {
+		sql += " RESTART IDENTITY"
+	}
+	if opts.Cascade {
+		sql += " CASCADE"
+	}
+
+	err := WithTx(ctx, pgxConn, TxOptions{}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, sql)
+		return err
+	})
+	if err != nil {
+		logrus.Error("TruncateTables:" + err.Error())
+		return fmt.Errorf(errFormat, err)
+	}
+	logrus.Info("Truncated ", strings.Join(names, ", "))
+	return nil
+}
@@ -0,0 +1,69 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIdentifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		ident pgx.Identifier
+	}{
+		{"lookups", pgx.Identifier{"lookups"}},
+		{"public.lookups", pgx.Identifier{"public", "lookups"}},
+		{"Stocks.FundHistory", pgx.Identifier{"Stocks", "FundHistory"}},
+		{`"my.schema"."Fund ""A"""`, pgx.Identifier{"my.schema", `Fund "A"`}},
+		{"lookups; DROP TABLE lookups", pgx.Identifier{"lookups; DROP TABLE lookups"}},
+	}
+	for _, tt := range tests {
+		ident, err := postgres.ParseIdentifier(tt.name)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.ident, ident, tt.name)
+	}
+
+	for _, name := range []string{"", "a..b", "a.b.c", `"open`, `a"b`, `"a"b`, `""`} {
+		_, err := postgres.ParseIdentifier(name)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestTruncateTables(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	_, err := pgxConn.Exec(ctx, `
+CREATE SCHEMA IF NOT EXISTS "Truncate";
+DROP TABLE IF EXISTS "Truncate"."Child", "Truncate"."Parent";
+CREATE TABLE "Truncate"."Parent" (id SERIAL PRIMARY KEY);
+CREATE TABLE "Truncate"."Child" (parent_id INTEGER REFERENCES "Truncate"."Parent" (id));
+INSERT INTO "Truncate"."Parent" DEFAULT VALUES;
+INSERT INTO "Truncate"."Child" VALUES (1);`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// The child references the parent, so truncating the parent alone fails and nothing is truncated.
+	assert.NotNil(t, postgres.TruncateTables(ctx, pgxConn, []string{"Truncate.Parent"}, postgres.TruncateOptions{}))
+
+	var count int
+	assert.Nil(t, pgxConn.QueryRow(ctx, `SELECT COUNT(*) FROM "Truncate"."Parent"`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	err = postgres.TruncateTables(ctx, pgxConn, []string{"Truncate.Parent"},
+		postgres.TruncateOptions{Cascade: true, RestartIdentity: true})
+	assert.Nil(t, err)
+	assert.Nil(t, pgxConn.QueryRow(ctx, `SELECT COUNT(*) FROM "Truncate"."Child"`).Scan(&count))
+	assert.Equal(t, 0, count)
+
+	var id int
+	assert.Nil(t, pgxConn.QueryRow(ctx, `INSERT INTO "Truncate"."Parent" DEFAULT VALUES RETURNING id`).Scan(&id))
+	assert.Equal(t, 1, id)
+
+	assert.Nil(t, postgres.TruncateTable(pgxConn, "Truncate.Child"))
+	assert.NotNil(t, postgres.TruncateTable(pgxConn, "lookups; DROP TABLE lookups"))
+}
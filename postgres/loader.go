@@ -0,0 +1,403 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errUnknownTable   = errors.New("table not found")
+	errUnknownHeader  = errors.New("csv header does not match a column")
+	errNullNotAllowed = errors.New("null value in not null column")
+	errBadFieldCount  = errors.New("wrong number of fields")
+	errBadDate        = errors.New("unrecognized date format")
+
+	// DateLayouts are the date formats tried, in order, for DATE and TIMESTAMP columns.
+	DateLayouts = []string{
+		"2006-01-02",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05",
+		time.RFC3339,
+		"01/02/2006",
+		"1/2/2006",
+		"01/02/06",
+		"1/2/06",
+		"01/02/2006 15:04:05",
+		"Jan 2, 2006",
+		"2-Jan-2006",
+		"20060102",
+	}
+)
+
+// Column describes a table column as read from information_schema.columns.
+type Column struct {
+	Name     string
+	DataType string
+	Nullable bool
+}
+
+// ColumnParser converts a CSV cell into a value for a column.  Returning nil loads NULL.
+type ColumnParser func(value string) (any, error)
+
+// RowError is a CSV row that failed validation and was skipped.
+type RowError struct {
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: column %s: %q: %v", e.Line, e.Column, e.Value, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// LoadResult is the outcome of a CSV load.
+type LoadResult struct {
+	// Rows is the number of valid rows read from the CSV.
+	Rows     int64
+	Inserted int64
+	Updated  int64
+	// Skipped is the number of rows not written, either invalid or, when upserting, already up to date.
+	Skipped int64
+	// Errors are the first MaxRowErrorsKept invalid rows, Invalid counts them all.
+	Errors  []RowError
+	Invalid int64
+}
+
+// MaxRowErrorsKept is how many invalid rows a LoadResult describes, so a file in the wrong format does not hold an
+// error for each of its rows.
+const MaxRowErrorsKept = 100
+
+// Loader loads CSV files into a table, converting each cell to the column's type.
+type Loader struct {
+	pool *pgxpool.Pool
+	// Table is the table name, optionally schema qualified, see ParseIdentifier.
+	Table string
+	// Mapping maps CSV headers to column names.  Headers that are not mapped must match a column name, ignoring case.
+	// A header mapped to "" is skipped.
+	Mapping map[string]string
+	// Parsers override the parser for a column by column name.
+	Parsers map[string]ColumnParser
+	// TypeParsers override the parser for every column of a data type, e.g. "numeric".
+	TypeParsers map[string]ColumnParser
+	// NullValues are the cell values loaded as NULL.  By default only a blank cell is.
+	NullValues []string
+	// Mode is how the rows are written, LoadAppend by default.
+	Mode LoadMode
+	// MaxErrors stops the load once more rows than this have failed, zero means no limit.
+	MaxErrors int
+	// Progress is called every ProgressInterval rows, DefaultProgressInterval when zero, and at the end of the load.
+	Progress         ProgressFunc
+	ProgressInterval int64
+}
+
+// NewLoader returns a loader for the table.
+func NewLoader(pool *pgxpool.Pool, table string) *Loader {
+	return &Loader{
+		pool:        pool,
+		Table:       table,
+		Mapping:     make(map[string]string),
+		Parsers:     make(map[string]ColumnParser),
+		TypeParsers: make(map[string]ColumnParser),
+		NullValues:  []string{""},
+	}
+}
+
+// TableColumns returns the columns of the table, in table order.  The table is in the current schema unless its name
+// is schema qualified.
+func TableColumns(ctx context.Context, pool *pgxpool.Pool, table string) ([]Column, error) {
+	ident, err := ParseIdentifier(table)
+	if err != nil {
+		return nil, err
+	}
+	schema, name := schemaAndTable(ident)
+
+	rows, err := pool.Query(ctx, `
+SELECT column_name, data_type, is_nullable = 'YES'
+FROM information_schema.columns
+WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2
+ORDER BY ordinal_position`, schema, name)
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	columns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Column, error) {
+		var c Column
+		err := row.Scan(&c.Name, &c.DataType, &c.Nullable)
+		return c, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", errUnknownTable, table)
+	}
+	return columns, nil
+}
+
+// LoadFile loads a CSV file whose first row is the header.
+func (l *Loader) LoadFile(ctx context.Context, fileName string) (*LoadResult, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+	defer file.Close()
+
+	return l.Load(ctx, file)
+}
+
+// Load loads CSV data, optionally gzipped, whose first row is the header.  Rows are streamed into the table as they
+// are read.  Rows that fail to convert are skipped and returned in the result's Errors, the rest are copied into the
+// table.
+func (l *Loader) Load(ctx context.Context, r io.Reader) (*LoadResult, error) {
+	table, err := ParseIdentifier(l.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, counter, err := openCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errNoRowsFromLoadRow
+		}
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	tableColumns, err := TableColumns(ctx, l.pool, l.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := l.mapHeader(header, tableColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	columnNames := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.column != nil {
+			columnNames = append(columnNames, f.column.Name)
+		}
+	}
+
+	source := &csvSource{
+		reader:  reader,
+		counter: counter,
+		convert: func(record []string, line int) ([]any, *RowError) {
+			return l.convert(record, fields, line)
+		},
+		skipErrors:       true,
+		maxErrors:        l.MaxErrors,
+		progress:         l.Progress,
+		progressInterval: l.ProgressInterval,
+	}
+	if source.progressInterval == 0 {
+		source.progressInterval = DefaultProgressInterval
+	}
+
+	result := &LoadResult{}
+	err = l.write(ctx, table, columnNames, source, result)
+	result.Errors, result.Invalid = source.rowErrors, source.invalid
+	result.Skipped += result.Invalid
+	if len(result.Errors) > 0 {
+		logrus.Warn("Skipped ", result.Invalid, " invalid rows of ", l.Table, ", the first ", result.Errors[0].Error())
+	}
+	if err != nil {
+		// Every mode loads in a single COPY or transaction, so nothing was loaded.
+		result.Rows, result.Inserted, result.Updated = 0, 0, 0
+		return result, fmt.Errorf(errFormat, err)
+	}
+	if result.Rows == 0 {
+		return result, errNoRowsFromLoadRow
+	}
+	logrus.Info("Loaded ", result.Rows, " rows into ", l.Table, " inserted ", result.Inserted, " updated ",
+		result.Updated, " skipped ", result.Skipped)
+	return result, nil
+}
+
+// loadField is a CSV field and the column and parser it loads into.  A nil column skips the field.
+type loadField struct {
+	column *Column
+	parser ColumnParser
+}
+
+func (l *Loader) mapHeader(header []string, columns []Column) ([]loadField, error) {
+	byName := make(map[string]*Column)
+	for i := range columns {
+		byName[strings.ToLower(columns[i].Name)] = &columns[i]
+	}
+
+	fields := make([]loadField, len(header))
+	var unknown []string
+	for i, h := range header {
+		name, mapped := l.Mapping[h]
+		if !mapped {
+			name = strings.TrimSpace(h)
+		}
+		if mapped && name == "" {
+			continue
+		}
+
+		column, ok := byName[strings.ToLower(name)]
+		if !ok {
+			unknown = append(unknown, h)
+			continue
+		}
+		fields[i] = loadField{column: column, parser: l.parser(*column)}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", errUnknownHeader, strings.Join(unknown, ", "))
+	}
+	return fields, nil
+}
+
+func (l *Loader) parser(column Column) ColumnParser {
+	if p, ok := l.Parsers[column.Name]; ok {
+		return p
+	}
+	if p, ok := l.TypeParsers[column.DataType]; ok {
+		return p
+	}
+	return ParserForType(column.DataType)
+}
+
+func (l *Loader) isNull(value string) bool {
+	for _, n := range l.NullValues {
+		if strings.TrimSpace(value) == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Loader) convert(record []string, fields []loadField, line int) ([]any, *RowError) {
+	if len(record) != len(fields) {
+		return nil, &RowError{Line: line, Err: fmt.Errorf("%w: %d, expected %d", errBadFieldCount, len(record), len(fields))}
+	}
+
+	row := make([]any, 0, len(fields))
+	for i, f := range fields {
+		if f.column == nil {
+			continue
+		}
+
+		var (
+			value any
+			err   error
+		)
+		if !l.isNull(record[i]) {
+			value, err = f.parser(record[i])
+		}
+		if err == nil && value == nil && !f.column.Nullable {
+			err = errNullNotAllowed
+		}
+		if err != nil {
+			return nil, &RowError{Line: line, Column: f.column.Name, Value: record[i], Err: err}
+		}
+		row = append(row, value)
+	}
+	return row, nil
+}
+
+// ParserForType returns the default parser for a Postgres data type.  Unknown types are loaded as text.
+func ParserForType(dataType string) ColumnParser {
+	switch dataType {
+	case "numeric":
+		return ParseNumeric
+	case "smallint", "integer", "bigint":
+		return ParseInteger
+	case "real", "double precision":
+		return ParseFloat
+	case "date", "timestamp without time zone", "timestamp with time zone":
+		return ParseDate
+	case "boolean":
+		return ParseBool
+	}
+	return ParseText
+}
+
+// cleanNumber strips the currency, grouping and percent characters FloatParse ignores.
+func cleanNumber(value string) string {
+	return strings.NewReplacer(",", "", "$", "", "#", "", "%", "", " ", "").Replace(strings.TrimSpace(value))
+}
+
+// ParseText loads the cell as is.
+func ParseText(value string) (any, error) {
+	return value, nil
+}
+
+// ParseNumeric parses currency and plain numbers exactly, without going through a float.
+func ParseNumeric(value string) (any, error) {
+	d, err := utils.ParseDecimal(cleanNumber(value))
+	if err != nil {
+		return nil, err
+	}
+	return d.NumericValue()
+}
+
+// ParseInteger parses whole numbers, allowing grouping characters.
+func ParseInteger(value string) (any, error) {
+	return strconv.ParseInt(cleanNumber(value), 10, 64)
+}
+
+// ParseFloat parses numbers with utils.FloatParse.
+func ParseFloat(value string) (any, error) {
+	return utils.FloatParse(value)
+}
+
+// ParseBool parses true/false, yes/no and 1/0.
+func ParseBool(value string) (any, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(strings.TrimSpace(value))
+}
+
+// ParseDate parses a date or timestamp in any of DateLayouts.
+func ParseDate(value string) (any, error) {
+	return parseDate(value, DateLayouts)
+}
+
+// DateParser returns a parser for dates in the given layouts.
+func DateParser(layouts ...string) ColumnParser {
+	return func(value string) (any, error) {
+		return parseDate(value, layouts)
+	}
+}
+
+func parseDate(value string, layouts []string) (any, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return nil, errBadDate
+}
@@ -0,0 +1,107 @@
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsers(t *testing.T) {
+	type parserTests struct {
+		Description string
+		Parser      postgres.ColumnParser
+		Input       string
+		Expected    any
+		Error       bool
+	}
+
+	tests := []parserTests{
+		{Description: "Currency", Parser: postgres.ParseNumeric, Input: "$1,000.99", Expected: "1000.99"},
+		{Description: "Percent", Parser: postgres.ParseNumeric, Input: "12.5%", Expected: "12.5"},
+		{Description: "Junk Numeric", Parser: postgres.ParseNumeric, Input: "Junk", Error: true},
+		{Description: "Integer", Parser: postgres.ParseInteger, Input: "1,234", Expected: int64(1234)},
+		{Description: "ISO Date", Parser: postgres.ParseDate, Input: "2024-06-19", Expected: time.Date(2024, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{Description: "US Date", Parser: postgres.ParseDate, Input: "6/19/2024", Expected: time.Date(2024, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{Description: "Bad Date", Parser: postgres.ParseDate, Input: "19.06.2024", Error: true},
+		{Description: "Custom Date", Parser: postgres.DateParser("02.01.2006"), Input: "19.06.2024", Expected: time.Date(2024, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{Description: "Yes", Parser: postgres.ParseBool, Input: "Yes", Expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Description, func(t *testing.T) {
+			result, err := tc.Parser(tc.Input)
+			if tc.Error {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			if n, ok := result.(pgtype.Numeric); ok {
+				result, _ = n.Value()
+			}
+			assert.Equal(t, tc.Expected, result)
+		})
+	}
+}
+
+func TestLoader_Load(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	if err := postgres.TruncateTable(pgxConn, "test_history"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	data := `Symbol,Date,Close,Adj Close,Volume,Notes
+HD,06/17/2024,"$345.67",345.67,"1,000",first
+HD,2024-06-18,346.01,,2000,second
+HD,junk,346.50,346.50,3000,bad date
+,2024-06-20,347.00,347.00,4000,no symbol
+HD,2024-06-21,348.00,348.00,5000,last
+`
+	loader := postgres.NewLoader(pgxConn, "test_history")
+	loader.Mapping["Adj Close"] = "adj_close"
+	loader.Mapping["Notes"] = ""
+
+	result, err := loader.Load(ctx, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, int64(3), result.Rows)
+	if assert.Len(t, result.Errors, 2) {
+		assert.Equal(t, 4, result.Errors[0].Line)
+		assert.Equal(t, "date", result.Errors[0].Column)
+		assert.Equal(t, 5, result.Errors[1].Line)
+		assert.Equal(t, "symbol", result.Errors[1].Column)
+	}
+
+	assert.Equal(t, int64(2), result.Invalid)
+
+	// Only the first invalid rows are kept, but all are counted.
+	var bad strings.Builder
+	bad.WriteString("Symbol,Date,Close\n")
+	for i := 0; i < postgres.MaxRowErrorsKept+50; i++ {
+		bad.WriteString("HD,junk,1\n")
+	}
+	bad.WriteString("HD,2024-06-24,349.00\n")
+	result, err = postgres.NewLoader(pgxConn, "test_history").Load(ctx, strings.NewReader(bad.String()))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Len(t, result.Errors, postgres.MaxRowErrorsKept)
+	assert.Equal(t, int64(postgres.MaxRowErrorsKept+50), result.Invalid)
+	assert.Equal(t, int64(postgres.MaxRowErrorsKept+50), result.Skipped)
+
+	var adjClose pgtype.Numeric
+	err = pgxConn.QueryRow(ctx, "SELECT adj_close FROM test_history WHERE date = '2024-06-18'").Scan(&adjClose)
+	assert.Nil(t, err)
+	assert.False(t, adjClose.Valid, "blank cells load as NULL")
+
+	loader = postgres.NewLoader(pgxConn, "test_history")
+	_, err = loader.Load(ctx, strings.NewReader(data))
+	assert.NotNil(t, err, "unmapped headers")
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LoadMode is how a Loader writes rows into its table.
+type LoadMode int
+
+const (
+	// LoadAppend copies the rows into the table and fails if any key already exists.
+	LoadAppend LoadMode = iota
+	// LoadReplace truncates the table and copies the rows in one transaction.
+	LoadReplace
+	// LoadUpsert copies the rows into a staging table and merges them into the table on its primary key.
+	LoadUpsert
+)
+
+// stagingOrderColumn numbers the staged rows so the last row in the file wins when a key repeats.
+const stagingOrderColumn = "_load_order"
+
+var errNoPrimaryKey = errors.New("table has no primary key")
+
+func (m LoadMode) String() string {
+	switch m {
+	case LoadAppend:
+		return "append"
+	case LoadReplace:
+		return "replace"
+	case LoadUpsert:
+		return "upsert"
+	}
+	return fmt.Sprintf("LoadMode(%d)", int(m))
+}
+
+// PrimaryKey returns the primary key columns of the table, in key order, read from the catalog.
+func PrimaryKey(ctx context.Context, pool *pgxpool.Pool, table string) ([]string, error) {
+	ident, err := ParseIdentifier(table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `
+SELECT a.attname
+FROM pg_index i
+JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+WHERE i.indrelid = $1::regclass AND i.indisprimary
+ORDER BY array_position(i.indkey::int2[], a.attnum)`, ident.Sanitize())
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	key, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: %s", errNoPrimaryKey, table)
+	}
+	return key, nil
+}
+
+// LoadTableWithHeadersMode loads a CSV file into the table like LoadTableWithHeaders, converting cells to the column
+// types and writing them with the mode.
+func LoadTableWithHeadersMode(ctx context.Context, pgxConn *pgxpool.Pool, tableName, fileName string, mode LoadMode) (*LoadResult, error) {
+	loader := NewLoader(pgxConn, tableName)
+	loader.Mode = mode
+	return loader.LoadFile(ctx, fileName)
+}
+
+func (l *Loader) write(ctx context.Context, table pgx.Identifier, columns []string, source *csvSource, result *LoadResult) error {
+	switch l.Mode {
+	case LoadAppend:
+		count, err := l.pool.CopyFrom(ctx, table, columns, source)
+		result.Rows, result.Inserted = count, count
+		return err
+
+	case LoadReplace:
+		return pgx.BeginFunc(ctx, l.pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, "TRUNCATE "+table.Sanitize()); err != nil {
+				return err
+			}
+			count, err := tx.CopyFrom(ctx, table, columns, source)
+			result.Rows, result.Inserted = count, count
+			return err
+		})
+
+	case LoadUpsert:
+		key, err := PrimaryKey(ctx, l.pool, l.Table)
+		if err != nil {
+			return err
+		}
+		for _, k := range key {
+			if !isKey(columns, k) {
+				return fmt.Errorf("%w: key column %s is not in the file", errUnknownHeader, k)
+			}
+		}
+		return pgx.BeginFunc(ctx, l.pool, func(tx pgx.Tx) error {
+			return l.upsert(ctx, tx, table, columns, key, source, result)
+		})
+	}
+	return fmt.Errorf("unknown load mode: %s", l.Mode)
+}
+
+// upsert copies the rows into a temporary staging table shaped like the target and merges them with
+// INSERT ... ON CONFLICT DO UPDATE.  Rows that match the existing row exactly are left alone and counted as skipped.
+func (l *Loader) upsert(ctx context.Context, tx pgx.Tx, ident pgx.Identifier, columns, key []string, source *csvSource,
+	result *LoadResult) error {
+	table := ident.Sanitize()
+	// Temporary tables live in their own schema, so the staging table is never schema qualified.
+	_, name := schemaAndTable(ident)
+	staging := pgx.Identifier{"staging_" + name}
+
+	_, err := tx.Exec(ctx, fmt.Sprintf("CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP",
+		staging.Sanitize(), table))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s BIGSERIAL", staging.Sanitize(), stagingOrderColumn))
+	if err != nil {
+		return err
+	}
+
+	result.Rows, err = tx.CopyFrom(ctx, staging, columns, source)
+	if err != nil {
+		return err
+	}
+
+	quoted := make([]string, len(columns))
+	var updates, current, excluded []string
+	for i, c := range columns {
+		quoted[i] = pgx.Identifier{c}.Sanitize()
+		if !isKey(key, c) {
+			updates = append(updates, quoted[i]+" = EXCLUDED."+quoted[i])
+			current = append(current, "target."+quoted[i])
+			excluded = append(excluded, "EXCLUDED."+quoted[i])
+		}
+	}
+	quotedKey := make([]string, len(key))
+	for i, k := range key {
+		quotedKey[i] = pgx.Identifier{k}.Sanitize()
+	}
+
+	conflict := "DO NOTHING"
+	if len(updates) > 0 {
+		conflict = fmt.Sprintf("DO UPDATE SET %s WHERE (%s) IS DISTINCT FROM (%s)",
+			strings.Join(updates, ", "), strings.Join(current, ", "), strings.Join(excluded, ", "))
+	}
+
+	// xmax is 0 for a freshly inserted row and set for one updated by ON CONFLICT.
+	sql := fmt.Sprintf(`INSERT INTO %[1]s AS target (%[2]s)
+SELECT DISTINCT ON (%[3]s) %[2]s FROM %[4]s ORDER BY %[3]s, %[5]s DESC
+ON CONFLICT (%[3]s) %[6]s
+RETURNING xmax = 0`, table, strings.Join(quoted, ", "), strings.Join(quotedKey, ", "), staging.Sanitize(),
+		stagingOrderColumn, conflict)
+
+	rows, err := tx.Query(ctx, sql)
+	if err != nil {
+		return err
+	}
+	var inserted bool
+	_, err = pgx.ForEachRow(rows, []any{&inserted}, func() error {
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	result.Skipped = result.Rows - result.Inserted - result.Updated
+	return nil
+}
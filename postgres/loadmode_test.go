@@ -0,0 +1,65 @@
+package postgres_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	key, err := postgres.PrimaryKey(ctx, pgxConn, "dividend_history")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"symbol", "year", "month"}, key)
+}
+
+func TestLoader_Modes(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	if err := postgres.TruncateTable(pgxConn, "test_history"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	first := "symbol,date,close\nHD,2024-06-17,345.67\nHD,2024-06-18,346.01\n"
+	loader := postgres.NewLoader(pgxConn, "test_history")
+	result, err := loader.Load(ctx, strings.NewReader(first))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.Inserted)
+
+	// Appending the same rows again fails on the primary key.
+	_, err = loader.Load(ctx, strings.NewReader(first))
+	assert.NotNil(t, err)
+
+	second := "symbol,date,close\nHD,2024-06-17,345.67\nHD,2024-06-18,346.50\nHD,2024-06-20,347.00\nHD,2024-06-20,347.25\n"
+	loader.Mode = postgres.LoadUpsert
+	result, err = loader.Load(ctx, strings.NewReader(second))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), result.Rows)
+	assert.Equal(t, int64(1), result.Inserted)
+	assert.Equal(t, int64(1), result.Updated)
+	assert.Equal(t, int64(2), result.Skipped)
+
+	var closePrice string
+	err = pgxConn.QueryRow(ctx, "SELECT close::text FROM test_history WHERE date = '2024-06-20'").Scan(&closePrice)
+	assert.Nil(t, err)
+	assert.Equal(t, "347.25", closePrice, "the last row for a key wins")
+
+	fileName := filepath.Join(t.TempDir(), "test_history.csv")
+	assert.Nil(t, os.WriteFile(fileName, []byte(first), 0o600))
+	result, err = postgres.LoadTableWithHeadersMode(ctx, pgxConn, "test_history", fileName, postgres.LoadReplace)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.Inserted)
+
+	var count int
+	err = pgxConn.QueryRow(ctx, "SELECT COUNT(*) FROM test_history").Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
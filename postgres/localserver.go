@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TestBackendEnv selects how NewTestServer starts Postgres: "docker", the default, or "local" to run the
+	// postgres binaries installed on the machine.
+	TestBackendEnv = "PG_TEST_BACKEND"
+	// TestBinDirEnv is the directory holding initdb and postgres for the local backend.  When it is not set they are
+	// looked for on the PATH, then with pg_config, then in /usr/lib/postgresql/*/bin.
+	TestBinDirEnv = "PG_TEST_BINDIR"
+
+	localServerStartTimeout = 30 * time.Second
+	localServerStopTimeout  = 10 * time.Second
+)
+
+var (
+	errUnknownTestBackend = errors.New("unknown test backend")
+	// ErrPostgresNotFound is returned by NewLocalTestServer when initdb or postgres can not be found.
+	ErrPostgresNotFound = errors.New("postgres binaries not found")
+	errRunningAsRoot    = errors.New("postgres can not run as root")
+)
+
+// NewTestServer starts the Postgres server selected by the PG_TEST_BACKEND environment variable: a container, or the
+// locally installed binaries.  Call Terminate to stop it.
+func NewTestServer(ctx context.Context) (*TestServer, error) {
+	switch backend := strings.ToLower(utils.GetEnv(TestBackendEnv, "docker")); backend {
+	case "docker":
+		return NewContainerTestServer(ctx)
+	case "local":
+		return NewLocalTestServer(ctx, os.Getenv(TestBinDirEnv))
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownTestBackend, backend)
+	}
+}
+
+// findPostgresBin returns the path of a Postgres program, in binDir when it is set.
+func findPostgresBin(name, binDir string) (string, error) {
+	if binDir != "" {
+		path := filepath.Join(binDir, name)
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("%w: %w", ErrPostgresNotFound, err)
+		}
+		return path, nil
+	}
+
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	if out, err := exec.Command("pg_config", "--bindir").Output(); err == nil {
+		path := filepath.Join(strings.TrimSpace(string(out)), name)
+		if _, err = os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	// Debian and Ubuntu install each version in its own directory off the PATH, use the newest.
+	matches, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql", "*", "bin", name))
+	if len(matches) > 0 {
+		sort.Slice(matches, func(i, j int) bool {
+			return versionOf(matches[i]) > versionOf(matches[j])
+		})
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("%w: %s, set %s", ErrPostgresNotFound, name, TestBinDirEnv)
+}
+
+// versionOf returns the major version in a /usr/lib/postgresql/<version>/bin path.
+func versionOf(path string) int {
+	var version int
+	_, _ = fmt.Sscanf(filepath.Base(filepath.Dir(filepath.Dir(path))), "%d", &version)
+	return version
+}
+
+// freePort returns a TCP port that was free when it was checked.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// syncBuffer is a buffer the server's output can be written to while it is read.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// NewLocalTestServer initializes a cluster in a temporary directory and runs the locally installed postgres on a random
+// port, without Docker.  binDir is the directory holding initdb and postgres, found as described for PG_TEST_BINDIR
+// when it is empty.  The cluster trusts local connections and skips fsync, it is only for tests.  Terminate stops the
+// server and removes the directory.
+func NewLocalTestServer(ctx context.Context, binDir string) (*TestServer, error) {
+	if os.Geteuid() == 0 {
+		return nil, errRunningAsRoot
+	}
+
+	initdb, err := findPostgresBin("initdb", binDir)
+	if err != nil {
+		return nil, err
+	}
+	postgresBin, err := findPostgresBin("postgres", binDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "keputils-postgres-")
+	if err != nil {
+		return nil, err
+	}
+	dataDir := filepath.Join(dir, "data")
+
+	out, err := exec.CommandContext(ctx, initdb, "-D", dataDir, "-U", "postgres", "-A", "trust", "-E", "UTF8",
+		"--no-locale", "--no-sync").CombinedOutput()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %w: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	output := &syncBuffer{}
+	cmd := exec.Command(postgresBin, "-D", dataDir, "-p", fmt.Sprint(port), "-k", dir,
+		"-c", "listen_addresses=127.0.0.1", "-c", "fsync=off", "-c", "synchronous_commit=off",
+		"-c", "full_page_writes=off")
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err = cmd.Start(); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	stop := func(ctx context.Context) error {
+		defer os.RemoveAll(dir)
+		select {
+		case <-exited:
+			return nil
+		default:
+		}
+
+		// SIGINT is a fast shutdown, it disconnects clients without waiting for them.
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			_ = cmd.Process.Kill()
+		}
+		select {
+		case <-exited:
+			return nil
+		case <-time.After(localServerStopTimeout):
+		case <-ctx.Done():
+		}
+		_ = cmd.Process.Kill()
+		<-exited
+		return nil
+	}
+
+	serverURL := fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+	if err = waitForServer(ctx, serverURL, exited); err != nil {
+		_ = stop(context.Background())
+		return nil, fmt.Errorf("%w: %s", err, output.String())
+	}
+
+	logrus.Info("Started postgres ", postgresBin, " on port ", port)
+	server := NewTestServerFromURL(serverURL)
+	server.stop = stop
+	return server, nil
+}
+
+// waitForServer waits until the server accepts connections, it exits, or it takes too long to start.
+func waitForServer(ctx context.Context, serverURL string, exited <-chan struct{}) error {
+	ctx, cancel := context.WithTimeout(ctx, localServerStartTimeout)
+	defer cancel()
+
+	for {
+		conn, err := pgx.Connect(ctx, serverURL)
+		if err == nil {
+			return conn.Close(ctx)
+		}
+
+		select {
+		case <-exited:
+			return errors.New("postgres exited")
+		case <-ctx.Done():
+			return fmt.Errorf("postgres did not start: %w", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
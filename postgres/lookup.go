@@ -0,0 +1,342 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LookupsChannel is notified by a trigger on the lookups table whenever it changes.
+	LookupsChannel = "lookups_changed"
+	// DefaultLookupMinScore is the lowest similarity a fuzzy match needs by default.
+	DefaultLookupMinScore = 0.8
+)
+
+var (
+	// securitySuffixes are dropped from the end of security names before they are compared.
+	securitySuffixes = map[string]bool{
+		"inc": true, "incorporated": true, "corp": true, "corporation": true, "co": true, "company": true,
+		"ltd": true, "limited": true, "plc": true, "llc": true, "lp": true, "sa": true, "ag": true, "nv": true,
+	}
+
+	errLookupNotLoaded = errors.New("lookups have not been loaded")
+)
+
+// LookupMatch is the lookup a security name resolved to.
+type LookupMatch struct {
+	Security string
+	Symbol   string
+	// Score is the similarity of the names, 1 for an exact match.
+	Score float64
+}
+
+// UnmatchedSecurity is a security name in the transactions table that has no lookup.
+type UnmatchedSecurity struct {
+	Security     string
+	Transactions int64
+	// Suggestion is the closest fuzzy match, nil when there is none.
+	Suggestion *LookupMatch
+}
+
+// lookupEntry is a lookup with its normalized name.
+type lookupEntry struct {
+	security   string
+	symbol     string
+	normalized string
+	words      []string
+}
+
+// LookupService resolves security names to symbols from an in-memory copy of the lookups table.  It is safe for
+// concurrent use, and the copy can be refreshed while it is used.
+type LookupService struct {
+	pool    *pgxpool.Pool
+	lookups LookupRepository
+	// MinScore is the lowest similarity, from 0 to 1, a fuzzy match needs.
+	MinScore float64
+
+	mu           sync.RWMutex
+	loaded       bool
+	entries      []lookupEntry
+	byNormalized map[string]*lookupEntry
+	bySymbol     map[string][]*lookupEntry
+	refreshedAt  time.Time
+}
+
+// NewLookupService returns a service for the lookups table.  Call Refresh to load it.
+func NewLookupService(pool *pgxpool.Pool) *LookupService {
+	return &LookupService{pool: pool, lookups: NewLookupRepository(pool), MinScore: DefaultLookupMinScore}
+}
+
+// normalizeSecurity lower cases the name, drops its punctuation and company suffixes like "Inc." and returns it and
+// its words.
+func normalizeSecurity(name string) (string, []string) {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '&'
+	})
+	for len(words) > 1 && securitySuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	}
+	return strings.Join(words, " "), words
+}
+
+// Refresh reloads the lookups table.
+func (s *LookupService) Refresh(ctx context.Context) error {
+	lookups, err := s.lookups.All(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]lookupEntry, 0, len(lookups))
+	for _, l := range lookups {
+		if !l.Symbol.Valid || l.Symbol.String == "" {
+			continue
+		}
+		normalized, words := normalizeSecurity(l.Security)
+		entries = append(entries, lookupEntry{security: l.Security, symbol: l.Symbol.String, normalized: normalized, words: words})
+	}
+
+	byNormalized := make(map[string]*lookupEntry, len(entries))
+	bySymbol := make(map[string][]*lookupEntry)
+	for i := range entries {
+		e := &entries[i]
+		if _, ok := byNormalized[e.normalized]; !ok {
+			byNormalized[e.normalized] = e
+		}
+		key := strings.ToUpper(e.symbol)
+		bySymbol[key] = append(bySymbol[key], e)
+	}
+
+	s.mu.Lock()
+	s.loaded = true
+	s.entries = entries
+	s.byNormalized = byNormalized
+	s.bySymbol = bySymbol
+	s.refreshedAt = time.Now()
+	s.mu.Unlock()
+
+	logrus.Info("Loaded ", len(entries), " lookups")
+	return nil
+}
+
+// RefreshedAt returns when the lookups were last loaded, zero if they have not been.
+func (s *LookupService) RefreshedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.refreshedAt
+}
+
+// RefreshEvery reloads the lookups every interval until ctx is done.  Failed refreshes are logged and the previous
+// lookups kept.
+func (s *LookupService) RefreshEvery(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				logrus.Error("LookupService:" + err.Error())
+			}
+		}
+	}
+}
+
+// Listen reloads the lookups whenever the lookups table changes, using LISTEN on LookupsChannel, until ctx is done.
+// It holds a connection out of the pool while it runs.
+func (s *LookupService) Listen(ctx context.Context) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf(errFormat, err)
+	}
+	// The connection is closed rather than returned so the pool never hands out a listening connection.
+	listener := conn.Hijack()
+	defer listener.Close(context.Background())
+
+	if _, err = listener.Exec(ctx, "LISTEN "+pgx.Identifier{LookupsChannel}.Sanitize()); err != nil {
+		return fmt.Errorf(errFormat, err)
+	}
+	// Changes made before LISTEN took effect would otherwise be missed.
+	if err = s.Refresh(ctx); err != nil {
+		return err
+	}
+
+	for {
+		if _, err = listener.WaitForNotification(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf(errFormat, err)
+		}
+		if err = s.Refresh(ctx); err != nil {
+			logrus.Error("LookupService:" + err.Error())
+		}
+	}
+}
+
+// Securities returns the security names that map to the symbol, ignoring case.
+func (s *LookupService) Securities(symbol string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var securities []string
+	for _, e := range s.bySymbol[strings.ToUpper(strings.TrimSpace(symbol))] {
+		securities = append(securities, e.security)
+	}
+	return securities
+}
+
+// Match resolves a security name, or a symbol, to a lookup.  Names are compared ignoring case, punctuation and company
+// suffixes, and when none is the same the most similar name scoring at least MinScore is used.  It returns
+// ErrNotFound when no name scores MinScore.
+func (s *LookupService) Match(name string) (*LookupMatch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.loaded {
+		return nil, errLookupNotLoaded
+	}
+
+	if entries, ok := s.bySymbol[strings.ToUpper(strings.TrimSpace(name))]; ok {
+		return &LookupMatch{Security: entries[0].security, Symbol: entries[0].symbol, Score: 1}, nil
+	}
+
+	normalized, words := normalizeSecurity(name)
+	if e, ok := s.byNormalized[normalized]; ok {
+		return &LookupMatch{Security: e.security, Symbol: e.symbol, Score: 1}, nil
+	}
+
+	best := s.closest(normalized, words)
+	if best == nil || best.Score < s.MinScore {
+		return nil, ErrNotFound
+	}
+	return best, nil
+}
+
+// Symbol returns the symbol of a security name, see Match.
+func (s *LookupService) Symbol(name string) (string, bool) {
+	match, err := s.Match(name)
+	if err != nil {
+		return "", false
+	}
+	return match.Symbol, true
+}
+
+// closest returns the entry most similar to the normalized name, nil when there are no entries.  The lock must be held.
+func (s *LookupService) closest(normalized string, words []string) *LookupMatch {
+	var best *LookupMatch
+	for i := range s.entries {
+		e := &s.entries[i]
+		score := max(wordSimilarity(words, e.words), editSimilarity(normalized, e.normalized))
+		if best == nil || score > best.Score {
+			best = &LookupMatch{Security: e.security, Symbol: e.symbol, Score: score}
+		}
+	}
+	return best
+}
+
+// wordSimilarity is the Sørensen–Dice coefficient of the words, so word order does not matter.
+func wordSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	counts := make(map[string]int)
+	for _, w := range a {
+		counts[w]++
+	}
+	common := 0
+	for _, w := range b {
+		if counts[w] > 0 {
+			counts[w]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+// editSimilarity is one less the Levenshtein distance as a fraction of the longer string, which tolerates typos.
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return 1 - float64(previous[len(rb)])/float64(longest)
+}
+
+// UnmatchedSecurities returns the security names in the transactions table with no exact lookup, most used first,
+// with the closest fuzzy match as a suggestion.
+func (s *LookupService) UnmatchedSecurities(ctx context.Context) ([]UnmatchedSecurity, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT security, COUNT(*)
+FROM transactions
+WHERE security IS NOT NULL AND security <> ''
+GROUP BY security`)
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	var securities []UnmatchedSecurity
+	var u UnmatchedSecurity
+	_, err = pgx.ForEachRow(rows, []any{&u.Security, &u.Transactions}, func() error {
+		securities = append(securities, u)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.loaded {
+		return nil, errLookupNotLoaded
+	}
+
+	var unmatched []UnmatchedSecurity
+	for _, u := range securities {
+		normalized, words := normalizeSecurity(u.Security)
+		if _, ok := s.byNormalized[normalized]; ok {
+			continue
+		}
+		if best := s.closest(normalized, words); best != nil && best.Score >= s.MinScore {
+			u.Suggestion = best
+		}
+		unmatched = append(unmatched, u)
+	}
+
+	sort.Slice(unmatched, func(i, j int) bool {
+		if unmatched[i].Transactions != unmatched[j].Transactions {
+			return unmatched[i].Transactions > unmatched[j].Transactions
+		}
+		return unmatched[i].Security < unmatched[j].Security
+	})
+	return unmatched, nil
+}
@@ -0,0 +1,107 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupService(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	if err := postgres.TruncateTables(ctx, pgxConn, []string{"lookups", "transactions"}, postgres.TruncateOptions{}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := postgres.LoadTableWithHeaders(ctx, pgxConn, "lookups", "./testdata/lookups.csv"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	service := postgres.NewLookupService(pgxConn)
+	_, err := service.Match("Home Depot")
+	assert.NotNil(t, err)
+	assert.Nil(t, service.Refresh(ctx))
+
+	tests := []struct {
+		name   string
+		symbol string
+		exact  bool
+	}{
+		{"Home Depot, Inc.", "HD", true},
+		{"HOME DEPOT INC", "HD", true},
+		{"hd", "HD", true},
+		{"Microsoft Corp", "MSFT", true},
+		{"Microsft Corporation", "MSFT", false},
+		{"Apple", "AAPL", true},
+	}
+	for _, tt := range tests {
+		match, err := service.Match(tt.name)
+		assert.Nil(t, err)
+		if assert.NotNil(t, match, tt.name) {
+			assert.Equal(t, tt.symbol, match.Symbol, tt.name)
+			assert.Equal(t, tt.exact, match.Score == 1, tt.name)
+		}
+	}
+
+	_, err = service.Match("Berkshire Hathaway")
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+	_, ok := service.Symbol("Berkshire Hathaway")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"Home Depot, Inc."}, service.Securities("hd"))
+
+	transactions := []postgres.Transaction{
+		{Id: 1, Date: pgtype.Timestamp{Time: time.Now(), Valid: true}, Security: pgtype.Text{String: "Home Depot Inc", Valid: true}},
+		{Id: 2, Date: pgtype.Timestamp{Time: time.Now(), Valid: true}, Security: pgtype.Text{String: "Microsft Corporation", Valid: true}},
+		{Id: 3, Date: pgtype.Timestamp{Time: time.Now(), Valid: true}, Security: pgtype.Text{String: "Berkshire Hathaway", Valid: true}},
+		{Id: 4, Date: pgtype.Timestamp{Time: time.Now(), Valid: true}, Security: pgtype.Text{String: "Berkshire Hathaway", Valid: true}},
+	}
+	if _, err = postgres.NewTransactionRepository(pgxConn).BatchInsert(ctx, transactions); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	unmatched, err := service.UnmatchedSecurities(ctx)
+	assert.Nil(t, err)
+	if assert.Len(t, unmatched, 2) {
+		assert.Equal(t, "Berkshire Hathaway", unmatched[0].Security)
+		assert.Equal(t, int64(2), unmatched[0].Transactions)
+		assert.Nil(t, unmatched[0].Suggestion)
+		if assert.NotNil(t, unmatched[1].Suggestion) {
+			assert.Equal(t, "MSFT", unmatched[1].Suggestion.Symbol)
+		}
+	}
+}
+
+func TestLookupService_Listen(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pgxConn := testServer.Database(t).Pool
+
+	service := postgres.NewLookupService(pgxConn)
+	listenCtx, stop := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() {
+		done <- service.Listen(listenCtx)
+	}()
+
+	// Wait for the first load, then change the table.
+	for service.RefreshedAt().IsZero() && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	loaded := service.RefreshedAt()
+	lookup := postgres.Lookup{Security: "Costco Wholesale Corporation", Symbol: pgtype.Text{String: "COST", Valid: true}}
+	assert.Nil(t, postgres.NewLookupRepository(pgxConn).Upsert(ctx, &lookup))
+
+	for service.RefreshedAt().Equal(loaded) && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	symbol, ok := service.Symbol("Costco Wholesale")
+	assert.True(t, ok)
+	assert.Equal(t, "COST", symbol)
+
+	stop()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
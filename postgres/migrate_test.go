@@ -0,0 +1,79 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/000002_add_index.up.sql":   {Data: []byte("CREATE INDEX migrate_test_idx ON migrate_test (name);")},
+		"migrations/000002_add_index.down.sql": {Data: []byte("DROP INDEX migrate_test_idx;")},
+		"migrations/000001_create.up.sql":      {Data: []byte("CREATE TABLE migrate_test (name VARCHAR(25));")},
+		"migrations/000001_create.down.sql":    {Data: []byte("DROP TABLE migrate_test;")},
+		"migrations/README.md":                 {Data: []byte("not a migration")},
+	}
+
+	migrations, err := postgres.LoadMigrations(fsys, "migrations")
+	assert.Nil(t, err)
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create", migrations[0].Name)
+	assert.Equal(t, "DROP INDEX migrate_test_idx;", migrations[1].Down)
+
+	fsys["migrations/000003_missing_up.down.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err = postgres.LoadMigrations(fsys, "migrations")
+	assert.NotNil(t, err)
+}
+
+func TestMigrator_MigrateTo(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	stock, err := postgres.NewStockMigrator(pgxConn)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	version, err := stock.Version(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+
+	statuses, err := stock.Status(ctx)
+	assert.Nil(t, err)
+	assert.True(t, statuses[0].Applied)
+
+	// The test migrations are numbered well after the stock ones so they can share the schema_migrations table.
+	fsys := fstest.MapFS{
+		"migrations/001001_create.up.sql":      {Data: []byte("CREATE TABLE migrate_test (name VARCHAR(25));")},
+		"migrations/001001_create.down.sql":    {Data: []byte("DROP TABLE migrate_test;")},
+		"migrations/001002_add_index.up.sql":   {Data: []byte("CREATE INDEX migrate_test_idx ON migrate_test (name);")},
+		"migrations/001002_add_index.down.sql": {Data: []byte("DROP INDEX migrate_test_idx;")},
+	}
+	migrator, err := postgres.NewMigrator(pgxConn, fsys, "migrations")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Nil(t, migrator.Up(ctx))
+	version, err = migrator.Version(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1002), version)
+
+	assert.Nil(t, migrator.MigrateTo(ctx, 1001))
+	var count int
+	err = pgxConn.QueryRow(ctx, "SELECT COUNT(*) FROM pg_indexes WHERE indexname = 'migrate_test_idx'").Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.NotNil(t, migrator.MigrateTo(ctx, 5))
+	assert.Nil(t, migrator.MigrateTo(ctx, 0))
+
+	// Reverting the test migrations leaves the stock tables alone.
+	version, err = stock.Version(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Transaction is a row in the transactions table.  NUMERIC columns use pgtype.Numeric so amounts are never rounded
+// through a float.  Date is NULL in rows imported without one.  The id column is NUMERIC, Id only scans whole numbers,
+// which is every id the loaders write.
+type Transaction struct {
+	Id               int64            `db:"id"`
+	Date             pgtype.Timestamp `db:"date"`
+	Type             pgtype.Text      `db:"type"`
+	Security         pgtype.Text      `db:"security"`
+	SecurityPayee    pgtype.Text      `db:"security_payee"`
+	Symbol           pgtype.Text      `db:"symbol"`
+	Description      pgtype.Text      `db:"description"`
+	Shares           pgtype.Numeric   `db:"shares"`
+	InvestmentAmount pgtype.Numeric   `db:"investment_amount"`
+	Amount           pgtype.Numeric   `db:"amount"`
+	Account          pgtype.Text      `db:"account"`
+}
+
+func (t Transaction) values() []any {
+	return []any{t.Id, t.Date, t.Type, t.Security, t.SecurityPayee, t.Symbol, t.Description, t.Shares,
+		t.InvestmentAmount, t.Amount, t.Account}
+}
+
+// FundHistory is a row in the fund_history table, one day of prices for a symbol.
+type FundHistory struct {
+	Symbol   string         `db:"symbol"`
+	Source   pgtype.Text    `db:"source"`
+	Date     time.Time      `db:"date"`
+	Open     pgtype.Numeric `db:"open"`
+	High     pgtype.Numeric `db:"high"`
+	Low      pgtype.Numeric `db:"low"`
+	Close    pgtype.Numeric `db:"close"`
+	AdjClose pgtype.Numeric `db:"adj_close"`
+	Volume   pgtype.Numeric `db:"volume"`
+}
+
+func (f FundHistory) values() []any {
+	return []any{f.Symbol, f.Source, f.Date, f.Open, f.High, f.Low, f.Close, f.AdjClose, f.Volume}
+}
+
+// PortfolioValue is a row in the portfolio_value table, a snapshot of a holding on a date.
+type PortfolioValue struct {
+	Date                time.Time      `db:"date"`
+	Name                pgtype.Text    `db:"name"`
+	Symbol              string         `db:"symbol"`
+	Type                pgtype.Text    `db:"type"`
+	Quote               pgtype.Numeric `db:"quote"`
+	PriceDayChange      pgtype.Numeric `db:"pricedaychange"`
+	PriceDayChangePct   pgtype.Numeric `db:"pricedaychangepct"`
+	Shares              pgtype.Numeric `db:"shares"`
+	CostBasis           pgtype.Numeric `db:"costbasis"`
+	MarketValue         pgtype.Numeric `db:"marketvalue"`
+	AverageCostPerShare pgtype.Numeric `db:"averagecostpershare"`
+	GainLoss12Month     pgtype.Numeric `db:"gainloss12month"`
+	GainLoss            pgtype.Numeric `db:"gainloss"`
+	GainLossPct         pgtype.Numeric `db:"gaillosspct"`
+}
+
+func (p PortfolioValue) values() []any {
+	return []any{p.Date, p.Name, p.Symbol, p.Type, p.Quote, p.PriceDayChange, p.PriceDayChangePct, p.Shares,
+		p.CostBasis, p.MarketValue, p.AverageCostPerShare, p.GainLoss12Month, p.GainLoss, p.GainLossPct}
+}
+
+// Dividend is a row in the dividends table, an announced dividend for a ticker.
+type Dividend struct {
+	Ticker          string           `db:"ticker"`
+	CashAmount      pgtype.Numeric   `db:"cash_amount"`
+	DeclarationDate time.Time        `db:"declaration_date"`
+	DividendType    pgtype.Text      `db:"dividend_type"`
+	ExDividendDate  pgtype.Timestamp `db:"ex_dividend_date"`
+	Frequency       pgtype.Numeric   `db:"frequency"`
+	PayDate         pgtype.Timestamp `db:"pay_date"`
+	RecordDate      pgtype.Timestamp `db:"record_date"`
+}
+
+func (d Dividend) values() []any {
+	return []any{d.Ticker, d.CashAmount, d.DeclarationDate, d.DividendType, d.ExDividendDate, d.Frequency,
+		d.PayDate, d.RecordDate}
+}
+
+// Lookup is a row in the lookups table mapping a security name to its symbol.
+type Lookup struct {
+	Security string      `db:"security"`
+	Symbol   pgtype.Text `db:"symbol"`
+}
+
+func (l Lookup) values() []any {
+	return []any{l.Security, l.Symbol}
+}
+
+// DividendHistory is a row in the dividend_history table, the dividends for a symbol in a month.
+type DividendHistory struct {
+	Symbol string         `db:"symbol"`
+	Year   int            `db:"year"`
+	Month  int            `db:"month"`
+	Amount pgtype.Numeric `db:"amount"`
+}
+
+func (d DividendHistory) values() []any {
+	return []any{d.Symbol, d.Year, d.Month, d.Amount}
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NumericToRat converts a NUMERIC exactly.  It returns nil for NULL, NaN and infinity.
+func NumericToRat(n pgtype.Numeric) *big.Rat {
+	if !n.Valid || n.NaN || n.InfinityModifier != pgtype.Finite || n.Int == nil {
+		return nil
+	}
+
+	r := new(big.Rat).SetInt(n.Int)
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(abs64(int64(n.Exp))), nil)
+	if n.Exp >= 0 {
+		return r.Mul(r, new(big.Rat).SetInt(pow))
+	}
+	return r.Quo(r, new(big.Rat).SetInt(pow))
+}
+
+// RatToNumeric rounds r half away from zero to scale decimal places.  A nil r is NULL.
+func RatToNumeric(r *big.Rat, scale int32) pgtype.Numeric {
+	if r == nil {
+		return pgtype.Numeric{}
+	}
+
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	num := new(big.Int).Mul(r.Num(), pow)
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(r.Sign())))
+	}
+	return pgtype.Numeric{Int: quo, Exp: -scale, Valid: true}
+}
@@ -0,0 +1,45 @@
+package postgres_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumericToRat(t *testing.T) {
+	for _, s := range []string{"0", "1234.5678", "-0.001", "12345678901234567890.01"} {
+		var n pgtype.Numeric
+		assert.Nil(t, n.Scan(s))
+		expected, _ := new(big.Rat).SetString(s)
+		assert.Equal(t, expected.String(), postgres.NumericToRat(n).String(), s)
+	}
+	assert.Equal(t, "12000/1", postgres.NumericToRat(pgtype.Numeric{Int: big.NewInt(12), Exp: 3, Valid: true}).String())
+	assert.Nil(t, postgres.NumericToRat(pgtype.Numeric{}))
+	assert.Nil(t, postgres.NumericToRat(pgtype.Numeric{NaN: true, Valid: true}))
+}
+
+func TestRatToNumeric(t *testing.T) {
+	tests := []struct {
+		rat      string
+		scale    int32
+		expected string
+	}{
+		{"1/3", 4, "0.3333"},
+		{"2/3", 2, "0.67"},
+		{"-2/3", 2, "-0.67"},
+		{"0.125", 2, "0.13"},
+		{"-0.125", 2, "-0.13"},
+		{"0.124", 2, "0.12"},
+		{"12", 0, "12"},
+	}
+	for _, tt := range tests {
+		r, _ := new(big.Rat).SetString(tt.rat)
+		value, err := postgres.RatToNumeric(r, tt.scale).Value()
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, value, tt.rat)
+	}
+	assert.False(t, postgres.RatToNumeric(nil, 2).Valid)
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"testing"
 
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kpearce2430/keputils/postgres"
 	"github.com/sirupsen/logrus"
 )
@@ -16,25 +15,28 @@ import (
 //	lookupsCSV string
 //)
 
+// testServer gives each test its own database.
+var testServer *postgres.TestServer
+
 func TestMain(m *testing.M) {
 	ctx := context.Background()
-	postgresDBServer, _ := postgres.CreatePostgresTestServer(ctx)
-	defer func() {
-		_ = postgresDBServer.Terminate(ctx)
-	}()
+	server, err := postgres.NewTestServer(ctx)
+	if err != nil {
+		logrus.Error(err.Error())
+		os.Exit(1)
+	}
+	testServer = server
 
-	os.Exit(m.Run())
+	code := m.Run()
+	_ = server.Terminate(ctx)
+	os.Exit(code)
 }
 
 func TestPostgres_New(t *testing.T) {
 	t.Log("Test Postgres New")
 	ctx := context.Background()
 
-	pgxConn, err := pgxpool.New(ctx, os.Getenv("PG_DATABASE_URL"))
-	if err != nil {
-		t.Error(err.Error())
-		return
-	}
+	pgxConn := testServer.Database(t).Pool
 
 	selectStatement := `
 SELECT table_name
@@ -43,36 +45,20 @@ WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
 AND table_type = 'BASE TABLE';
 `
 
-	rows, err := pgxConn.Query(ctx, selectStatement)
-	defer rows.Close()
-	if err != nil {
-		logrus.Error(err.Error())
-		return
+	type table struct {
+		Name string `db:"table_name"`
 	}
-
-	var tables []string
-	// Iterate through the result set
-	num := 0
-	for rows.Next() {
-		var table string
-		err = rows.Scan(&table)
-		if err != nil {
-			logrus.Fatal(err.Error())
-		}
-		tables = append(tables, table)
-		num++
+	tables, err := postgres.QueryAll[table](ctx, pgxConn, selectStatement, nil)
+	if err != nil {
+		t.Fatal(err.Error())
 	}
 	t.Log(tables)
 }
 
 func TestPostgres_LoadTableWithHeaders(t *testing.T) {
 	ctx := context.Background()
-	pgxConn, err := pgxpool.New(ctx, os.Getenv("PG_DATABASE_URL"))
-	if err != nil {
-		t.Error(err.Error())
-		return
-	}
-	err = postgres.TruncateTable(pgxConn, "lookups")
+	pgxConn := testServer.Database(t).Pool
+	err := postgres.TruncateTable(pgxConn, "lookups")
 	if err != nil {
 		t.Error(err.Error())
 		return
@@ -84,23 +70,11 @@ func TestPostgres_LoadTableWithHeaders(t *testing.T) {
 	}
 
 	selectStatement := `SELECT symbol,security FROM lookups;`
-	rows, err := pgxConn.Query(ctx, selectStatement)
-	defer rows.Close()
+	lookups, err := postgres.QueryAll[postgres.Lookup](ctx, pgxConn, selectStatement, nil)
 	if err != nil {
-		logrus.Error(err.Error())
-		return
+		t.Fatal(err.Error())
 	}
-
-	// Iterate through the result set
-	num := 0
-	for rows.Next() {
-		var symbol, security string
-		err = rows.Scan(&symbol, &security)
-		if err != nil {
-			logrus.Error(err.Error())
-			return
-		}
-		t.Log("Symbol:", symbol, " Security:", security)
-		num++
+	for _, lookup := range lookups {
+		t.Log("Symbol:", lookup.Symbol.String, " Security:", lookup.Security)
 	}
 }
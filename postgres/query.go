@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotFound is returned by QueryOne when the query returns no rows.
+var ErrNotFound = errors.New("postgres: no rows found")
+
+// queryArgs passes the named arguments only when there are some, so SQL without them is sent as it is.
+func queryArgs(args pgx.NamedArgs) []any {
+	if len(args) == 0 {
+		return nil
+	}
+	return []any{args}
+}
+
+// QueryAll runs the query and returns every row as a T.  T must be a struct, its fields are matched to the columns by
+// their db tags, or their names when they have none.  Arguments are named in the SQL as @name.
+func QueryAll[T any](ctx context.Context, db DB, sql string, args pgx.NamedArgs) ([]T, error) {
+	rows, err := db.Query(ctx, sql, queryArgs(args)...)
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+	return results, nil
+}
+
+// QueryOne runs the query and returns its first row as a T, see QueryAll.  It returns ErrNotFound when there are no
+// rows.
+func QueryOne[T any](ctx context.Context, db DB, sql string, args pgx.NamedArgs) (T, error) {
+	var zero T
+	rows, err := db.Query(ctx, sql, queryArgs(args)...)
+	if err != nil {
+		return zero, fmt.Errorf(errFormat, err)
+	}
+
+	result, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[T])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return zero, ErrNotFound
+	}
+	if err != nil {
+		return zero, fmt.Errorf(errFormat, err)
+	}
+	return result, nil
+}
+
+// QueryIter runs the query when the sequence is ranged over and yields its rows one at a time as T, see QueryAll, so
+// large results are not held in memory.  An error ends the sequence.  Stopping early closes the rows.
+func QueryIter[T any](ctx context.Context, db DB, sql string, args pgx.NamedArgs) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		rows, err := db.Query(ctx, sql, queryArgs(args)...)
+		if err != nil {
+			yield(zero, fmt.Errorf(errFormat, err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			row, err := pgx.RowToStructByName[T](rows)
+			if err != nil {
+				yield(zero, fmt.Errorf(errFormat, err))
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err = rows.Err(); err != nil {
+			yield(zero, fmt.Errorf(errFormat, err))
+		}
+	}
+}
+
+// Exec runs a statement with named arguments, see QueryAll, and returns the number of rows it affected.
+func Exec(ctx context.Context, db DB, sql string, args pgx.NamedArgs) (int64, error) {
+	tag, err := db.Exec(ctx, sql, queryArgs(args)...)
+	if err != nil {
+		return 0, fmt.Errorf(errFormat, err)
+	}
+	return tag.RowsAffected(), nil
+}
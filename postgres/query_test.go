@@ -0,0 +1,53 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryHelpers(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	count, err := postgres.Exec(ctx, pgxConn, `
+INSERT INTO lookups (security, symbol)
+VALUES (@first, 'HD'), ('Apple Inc.', 'AAPL'), ('Microsoft Corporation', NULL)`, pgx.NamedArgs{"first": "Home Depot, Inc."})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	lookups, err := postgres.QueryAll[postgres.Lookup](ctx, pgxConn,
+		"SELECT security, symbol FROM lookups WHERE symbol IS NOT NULL ORDER BY symbol", nil)
+	assert.Nil(t, err)
+	if assert.Len(t, lookups, 2) {
+		assert.Equal(t, "AAPL", lookups[0].Symbol.String)
+		assert.Equal(t, "Home Depot, Inc.", lookups[1].Security)
+	}
+
+	lookup, err := postgres.QueryOne[postgres.Lookup](ctx, pgxConn,
+		"SELECT security, symbol FROM lookups WHERE security = @security", pgx.NamedArgs{"security": "Microsoft Corporation"})
+	assert.Nil(t, err)
+	assert.False(t, lookup.Symbol.Valid)
+
+	_, err = postgres.QueryOne[postgres.Lookup](ctx, pgxConn,
+		"SELECT security, symbol FROM lookups WHERE symbol = @symbol", pgx.NamedArgs{"symbol": "IBM"})
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+
+	var securities []string
+	for lookup, err := range postgres.QueryIter[postgres.Lookup](ctx, pgxConn,
+		"SELECT security, symbol FROM lookups ORDER BY security", nil) {
+		assert.Nil(t, err)
+		securities = append(securities, lookup.Security)
+		if len(securities) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"Apple Inc.", "Home Depot, Inc."}, securities)
+
+	for _, err := range postgres.QueryIter[postgres.Lookup](ctx, pgxConn, "SELECT missing FROM lookups", nil) {
+		assert.NotNil(t, err)
+	}
+}
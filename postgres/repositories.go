@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TransactionRepository reads and writes the transactions table.
+type TransactionRepository struct {
+	Repository[Transaction]
+}
+
+func NewTransactionRepository(pool *pgxpool.Pool) TransactionRepository {
+	return TransactionRepository{newRepository(pool, "transactions",
+		[]string{"id", "date", "type", "security", "security_payee", "symbol", "description", "shares",
+			"investment_amount", "amount", "account"},
+		[]string{"id"}, Transaction.values)}
+}
+
+// BySymbol returns the transactions for the symbol between from and to, inclusive, oldest first.
+func (r TransactionRepository) BySymbol(ctx context.Context, symbol string, from, to time.Time) ([]Transaction, error) {
+	return r.query(ctx, "symbol = $1 AND date >= $2 AND date <= $3", "date, id", 0, symbol, from, to)
+}
+
+// ByAccount returns the transactions in the account between from and to, inclusive, oldest first.
+func (r TransactionRepository) ByAccount(ctx context.Context, account string, from, to time.Time) ([]Transaction, error) {
+	return r.query(ctx, "account = $1 AND date >= $2 AND date <= $3", "date, id", 0, account, from, to)
+}
+
+// ByDate returns all the transactions between from and to, inclusive, oldest first.
+func (r TransactionRepository) ByDate(ctx context.Context, from, to time.Time) ([]Transaction, error) {
+	return r.query(ctx, "date >= $1 AND date <= $2", "date, id", 0, from, to)
+}
+
+// FundHistoryRepository reads and writes the fund_history table.
+type FundHistoryRepository struct {
+	Repository[FundHistory]
+}
+
+func NewFundHistoryRepository(pool *pgxpool.Pool) FundHistoryRepository {
+	return FundHistoryRepository{newRepository(pool, "fund_history",
+		[]string{"symbol", "source", "date", "open", "high", "low", "close", "adj_close", "volume"},
+		[]string{"symbol", "date"}, FundHistory.values)}
+}
+
+// BySymbol returns the prices for the symbol between from and to, inclusive, oldest first.
+func (r FundHistoryRepository) BySymbol(ctx context.Context, symbol string, from, to time.Time) ([]FundHistory, error) {
+	return r.query(ctx, "symbol = $1 AND date >= $2 AND date <= $3", "date", 0, symbol, from, to)
+}
+
+// Latest returns the most recent price for the symbol on or before the date, ErrNotFound when there is none.
+func (r FundHistoryRepository) Latest(ctx context.Context, symbol string, asOf time.Time) (*FundHistory, error) {
+	return r.queryOne(ctx, "symbol = $1 AND date <= $2", "date DESC", symbol, asOf)
+}
+
+// Recent returns up to n of the most recent prices on or before the date for each of the symbols, newest first, in a
+// single query.  Symbols without a price are missing from the map.
+func (r FundHistoryRepository) Recent(ctx context.Context, symbols []string, asOf time.Time, n int) (map[string][]FundHistory, error) {
+	sql := fmt.Sprintf(`SELECT f.* FROM unnest(@symbols::text[]) AS s(wanted)
+CROSS JOIN LATERAL (SELECT %s FROM %s WHERE symbol = s.wanted AND date <= @asOf ORDER BY date DESC LIMIT @n) AS f
+ORDER BY f.symbol, f.date DESC`, r.columnList(), r.identifier())
+	rows, err := QueryAll[FundHistory](ctx, r.db, sql, pgx.NamedArgs{"symbols": symbols, "asOf": asOf, "n": n})
+	if err != nil {
+		return nil, err
+	}
+
+	bySymbol := make(map[string][]FundHistory)
+	for _, row := range rows {
+		bySymbol[row.Symbol] = append(bySymbol[row.Symbol], row)
+	}
+	return bySymbol, nil
+}
+
+// PortfolioValueRepository reads and writes the portfolio_value table.
+type PortfolioValueRepository struct {
+	Repository[PortfolioValue]
+}
+
+func NewPortfolioValueRepository(pool *pgxpool.Pool) PortfolioValueRepository {
+	return PortfolioValueRepository{newRepository(pool, "portfolio_value",
+		[]string{"date", "name", "symbol", "type", "quote", "pricedaychange", "pricedaychangepct", "shares",
+			"costbasis", "marketvalue", "averagecostpershare", "gainloss12month", "gainloss", "gaillosspct"},
+		[]string{"symbol", "date"}, PortfolioValue.values)}
+}
+
+// BySymbol returns the snapshots of the symbol between from and to, inclusive, oldest first.
+func (r PortfolioValueRepository) BySymbol(ctx context.Context, symbol string, from, to time.Time) ([]PortfolioValue, error) {
+	return r.query(ctx, "symbol = $1 AND date >= $2 AND date <= $3", "date", 0, symbol, from, to)
+}
+
+// ByDate returns the snapshots of every symbol between from and to, inclusive, ordered by date and symbol.
+func (r PortfolioValueRepository) ByDate(ctx context.Context, from, to time.Time) ([]PortfolioValue, error) {
+	return r.query(ctx, "date >= $1 AND date <= $2", "date, symbol", 0, from, to)
+}
+
+// DividendRepository reads and writes the dividends table.
+type DividendRepository struct {
+	Repository[Dividend]
+}
+
+func NewDividendRepository(pool *pgxpool.Pool) DividendRepository {
+	return DividendRepository{newRepository(pool, "dividends",
+		[]string{"ticker", "cash_amount", "declaration_date", "dividend_type", "ex_dividend_date", "frequency",
+			"pay_date", "record_date"},
+		[]string{"ticker", "declaration_date"}, Dividend.values)}
+}
+
+// BySymbol returns the dividends for the ticker paid between from and to, inclusive, oldest first.
+func (r DividendRepository) BySymbol(ctx context.Context, ticker string, from, to time.Time) ([]Dividend, error) {
+	return r.query(ctx, "ticker = $1 AND pay_date >= $2 AND pay_date <= $3", "pay_date", 0, ticker, from, to)
+}
+
+// ByExDividendDate returns the dividends of every ticker going ex-dividend between from and to, inclusive.
+func (r DividendRepository) ByExDividendDate(ctx context.Context, from, to time.Time) ([]Dividend, error) {
+	return r.query(ctx, "ex_dividend_date >= $1 AND ex_dividend_date <= $2", "ex_dividend_date, ticker", 0, from, to)
+}
+
+// LookupRepository reads and writes the lookups table.
+type LookupRepository struct {
+	Repository[Lookup]
+}
+
+func NewLookupRepository(pool *pgxpool.Pool) LookupRepository {
+	return LookupRepository{newRepository(pool, "lookups", []string{"security", "symbol"}, []string{"security"},
+		Lookup.values)}
+}
+
+// All returns every lookup ordered by security.
+func (r LookupRepository) All(ctx context.Context) ([]Lookup, error) {
+	return r.query(ctx, "", "security", 0)
+}
+
+// BySymbol returns the securities that map to the symbol.
+func (r LookupRepository) BySymbol(ctx context.Context, symbol string) ([]Lookup, error) {
+	return r.query(ctx, "symbol = $1", "security", 0, symbol)
+}
+
+// DividendHistoryRepository reads and writes the dividend_history table.
+type DividendHistoryRepository struct {
+	Repository[DividendHistory]
+}
+
+func NewDividendHistoryRepository(pool *pgxpool.Pool) DividendHistoryRepository {
+	return DividendHistoryRepository{newRepository(pool, "dividend_history",
+		[]string{"symbol", "year", "month", "amount"}, []string{"symbol", "year", "month"}, DividendHistory.values)}
+}
+
+// BySymbol returns the monthly dividends for the symbol from fromYear through toYear, oldest first.
+func (r DividendHistoryRepository) BySymbol(ctx context.Context, symbol string, fromYear, toYear int) ([]DividendHistory, error) {
+	return r.query(ctx, "symbol = $1 AND year >= $2 AND year <= $3", "year, month", 0, symbol, fromYear, toYear)
+}
@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+var errWrongKeyCount = errors.New("wrong number of key values")
+
+// DB runs statements.  It is satisfied by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// Repository reads and writes the rows of a single table as T.  T's fields are matched to the columns by their db
+// tags.
+type Repository[T any] struct {
+	db      DB
+	table   string
+	columns []string
+	key     []string
+	values  func(T) []any
+}
+
+func newRepository[T any](pool *pgxpool.Pool, table string, columns, key []string, values func(T) []any) Repository[T] {
+	return Repository[T]{db: pool, table: table, columns: columns, key: key, values: values}
+}
+
+// InTx returns a copy of the repository that runs its statements in the transaction.
+func (r Repository[T]) InTx(tx pgx.Tx) Repository[T] {
+	r.db = tx
+	return r
+}
+
+// Table returns the name of the repository's table.
+func (r Repository[T]) Table() string {
+	return r.table
+}
+
+func (r Repository[T]) identifier() string {
+	return pgx.Identifier{r.table}.Sanitize()
+}
+
+func (r Repository[T]) columnList() string {
+	quoted := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		quoted[i] = pgx.Identifier{c}.Sanitize()
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func placeholders(start, count int) string {
+	p := make([]string, count)
+	for i := range p {
+		p[i] = fmt.Sprintf("$%d", start+i)
+	}
+	return strings.Join(p, ", ")
+}
+
+func (r Repository[T]) insertSql() string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", r.identifier(), r.columnList(), placeholders(1, len(r.columns)))
+}
+
+func (r Repository[T]) upsertSql() string {
+	key := make([]string, len(r.key))
+	for i, k := range r.key {
+		key[i] = pgx.Identifier{k}.Sanitize()
+	}
+
+	var updates []string
+	for _, c := range r.columns {
+		if !isKey(r.key, c) {
+			column := pgx.Identifier{c}.Sanitize()
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+
+	conflict := "DO NOTHING"
+	if len(updates) > 0 {
+		conflict = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	return fmt.Sprintf("%s ON CONFLICT (%s) %s", r.insertSql(), strings.Join(key, ", "), conflict)
+}
+
+func isKey(key []string, column string) bool {
+	for _, k := range key {
+		if k == column {
+			return true
+		}
+	}
+	return false
+}
+
+// Insert adds a row, failing if a row with the same key already exists.
+func (r Repository[T]) Insert(ctx context.Context, row *T) error {
+	if _, err := r.db.Exec(ctx, r.insertSql(), r.values(*row)...); err != nil {
+		return fmt.Errorf(errFormat, err)
+	}
+	return nil
+}
+
+// Upsert adds a row or replaces the row with the same key.
+func (r Repository[T]) Upsert(ctx context.Context, row *T) error {
+	if _, err := r.db.Exec(ctx, r.upsertSql(), r.values(*row)...); err != nil {
+		return fmt.Errorf(errFormat, err)
+	}
+	return nil
+}
+
+// BatchInsert copies the rows into the table and returns the number of rows copied.  It fails without adding any rows
+// if any key already exists.
+func (r Repository[T]) BatchInsert(ctx context.Context, rows []T) (int64, error) {
+	count, err := r.db.CopyFrom(ctx, pgx.Identifier{r.table}, r.columns, pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+		return r.values(rows[i]), nil
+	}))
+	if err != nil {
+		return 0, fmt.Errorf(errFormat, err)
+	}
+	logrus.Info("Loaded ", count, " rows into ", r.table)
+	return count, nil
+}
+
+// GetByKey returns the row with the primary key values, in key column order.  It returns ErrNotFound when there is no
+// row.
+func (r Repository[T]) GetByKey(ctx context.Context, key ...any) (*T, error) {
+	if len(key) != len(r.key) {
+		return nil, fmt.Errorf("%w: %s takes %d", errWrongKeyCount, r.table, len(r.key))
+	}
+
+	where := make([]string, len(r.key))
+	for i, k := range r.key {
+		where[i] = fmt.Sprintf("%s = $%d", pgx.Identifier{k}.Sanitize(), i+1)
+	}
+
+	return r.queryOne(ctx, strings.Join(where, " AND "), "", key...)
+}
+
+// queryOne returns the first row matching where in orderBy order, or ErrNotFound when there is none.
+func (r Repository[T]) queryOne(ctx context.Context, where, orderBy string, args ...any) (*T, error) {
+	rows, err := r.query(ctx, where, orderBy, 1, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rows[0], nil
+}
+
+// query selects the rows matching where, which may be empty, in orderBy order.  No more than limit rows are returned
+// when it is more than 0.
+func (r Repository[T]) query(ctx context.Context, where, orderBy string, limit int, args ...any) ([]T, error) {
+	sql := fmt.Sprintf("SELECT %s FROM %s", r.columnList(), r.identifier())
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	if orderBy != "" {
+		sql += " ORDER BY " + orderBy
+	}
+	if limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return nil, fmt.Errorf(errFormat, err)
+	}
+	return results, nil
+}
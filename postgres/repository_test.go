@@ -0,0 +1,124 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func numeric(t *testing.T, value string) pgtype.Numeric {
+	var n pgtype.Numeric
+	if err := n.Scan(value); err != nil {
+		t.Fatal(err.Error())
+	}
+	return n
+}
+
+func TestTransactionRepository(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	if err := postgres.TruncateTable(pgxConn, "transactions"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	repo := postgres.NewTransactionRepository(pgxConn)
+	transaction := postgres.Transaction{
+		Id:       1,
+		Date:     pgtype.Timestamp{Time: time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), Valid: true},
+		Type:     pgtype.Text{String: "Buy", Valid: true},
+		Security: pgtype.Text{String: "Home Depot, Inc.", Valid: true},
+		Symbol:   pgtype.Text{String: "HD", Valid: true},
+		Shares:   numeric(t, "10"),
+		Amount:   numeric(t, "-3456.78"),
+		Account:  pgtype.Text{String: "IRA", Valid: true},
+	}
+	assert.Nil(t, repo.Insert(ctx, &transaction))
+	assert.NotNil(t, repo.Insert(ctx, &transaction), "duplicate key")
+
+	transaction.Amount = numeric(t, "-3456.79")
+	assert.Nil(t, repo.Upsert(ctx, &transaction))
+
+	result, err := repo.GetByKey(ctx, 1)
+	assert.Nil(t, err)
+	if assert.NotNil(t, result) {
+		amount, _ := result.Amount.Value()
+		assert.Equal(t, "-3456.79", amount)
+		assert.Equal(t, "Home Depot, Inc.", result.Security.String)
+		assert.False(t, result.Description.Valid)
+	}
+
+	result, err = repo.GetByKey(ctx, 2)
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+	assert.Nil(t, result)
+
+	_, err = repo.GetByKey(ctx, 1, 2)
+	assert.NotNil(t, err)
+
+	count, err := repo.BatchInsert(ctx, []postgres.Transaction{
+		{Id: 2, Date: pgtype.Timestamp{Time: time.Date(2024, 6, 11, 0, 0, 0, 0, time.UTC), Valid: true}, Symbol: pgtype.Text{String: "HD", Valid: true}, Account: pgtype.Text{String: "IRA", Valid: true}},
+		{Id: 3, Date: pgtype.Timestamp{Time: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), Valid: true}, Symbol: pgtype.Text{String: "AAPL", Valid: true}, Account: pgtype.Text{String: "IRA", Valid: true}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	transactions, err := repo.BySymbol(ctx, "HD", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 2)
+
+	transactions, err = repo.ByAccount(ctx, "IRA", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 3)
+
+	// A row imported without a date does not break reading the others.
+	assert.Nil(t, repo.Insert(ctx, &postgres.Transaction{Id: 4, Symbol: pgtype.Text{String: "HD", Valid: true}}))
+	undated, err := repo.GetByKey(ctx, 4)
+	assert.Nil(t, err)
+	if assert.NotNil(t, undated) {
+		assert.False(t, undated.Date.Valid)
+	}
+	transactions, err = repo.BySymbol(ctx, "HD", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 2)
+}
+
+func TestFundHistoryRepository(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	if err := postgres.TruncateTable(pgxConn, "fund_history"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	repo := postgres.NewFundHistoryRepository(pgxConn)
+	_, err := repo.BatchInsert(ctx, []postgres.FundHistory{
+		{Symbol: "HD", Date: time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), Close: numeric(t, "345.67")},
+		{Symbol: "HD", Date: time.Date(2024, 6, 11, 0, 0, 0, 0, time.UTC), Close: numeric(t, "346.01")},
+	})
+	assert.Nil(t, err)
+
+	latest, err := repo.Latest(ctx, "HD", time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	if assert.NotNil(t, latest) {
+		assert.Equal(t, 11, latest.Date.Day())
+	}
+
+	_, err = repo.Latest(ctx, "HD", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, postgres.ErrNotFound)
+
+	recent, err := repo.Recent(ctx, []string{"HD", "MSFT"}, time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), 2)
+	assert.Nil(t, err)
+	if assert.Len(t, recent["HD"], 2) {
+		assert.Equal(t, 11, recent["HD"][0].Date.Day())
+		assert.Equal(t, 10, recent["HD"][1].Date.Day())
+	}
+	assert.NotContains(t, recent, "MSFT")
+
+	result, err := repo.GetByKey(ctx, "HD", time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.NotNil(t, result)
+}
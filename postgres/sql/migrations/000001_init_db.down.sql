@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS dividend_history;
+DROP TABLE IF EXISTS lookups;
+DROP TABLE IF EXISTS dividends;
+DROP TABLE IF EXISTS portfolio_value;
+DROP TABLE IF EXISTS test_history;
+DROP TABLE IF EXISTS fund_history;
+DROP TABLE IF EXISTS all_transactions;
+DROP TABLE IF EXISTS transactions;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS lookups_changed ON lookups;
+DROP FUNCTION IF EXISTS notify_lookups_changed();
@@ -0,0 +1,11 @@
+-- Notify listeners on the lookups_changed channel whenever the lookups table changes.
+CREATE OR REPLACE FUNCTION notify_lookups_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('lookups_changed', TG_OP);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS lookups_changed ON lookups;
+CREATE TRIGGER lookups_changed AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON lookups
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_lookups_changed();
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultProgressInterval is how many rows are loaded between progress callbacks.
+const DefaultProgressInterval = 10000
+
+var errTooManyRowErrors = errors.New("too many invalid rows")
+
+// ProgressFunc is called while a CSV loads with the rows loaded and the bytes of input read so far.
+type ProgressFunc func(rows, bytes int64)
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// openCSV returns a CSV reader over r, decompressing it when it is gzipped.  The counting reader reports the bytes of
+// r read, before decompression, so progress can be compared with the file size.
+func openCSV(r io.Reader) (*csv.Reader, *countingReader, error) {
+	counter := &countingReader{r: r}
+	buffered := bufio.NewReader(counter)
+
+	var input io.Reader = buffered
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, fmt.Errorf(errFormat, err)
+		}
+		input = gz
+	}
+
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return reader, counter, nil
+}
+
+// csvSource streams CSV records to CopyFrom one at a time so memory use does not grow with the file.
+type csvSource struct {
+	reader  *csv.Reader
+	counter *countingReader
+	// convert turns a record into the row's values.  A RowError skips the row when skipErrors is set.
+	convert    func(record []string, line int) ([]any, *RowError)
+	skipErrors bool
+	maxErrors  int
+	// rowErrors are the first MaxRowErrorsKept of the invalid rows, invalid counts all of them.
+	rowErrors []RowError
+	invalid   int64
+
+	progress         ProgressFunc
+	progressInterval int64
+
+	values []any
+	rows   int64
+	err    error
+}
+
+func (s *csvSource) Next() bool {
+	for {
+		record, err := s.reader.Read()
+		if errors.Is(err, io.EOF) {
+			s.report()
+			return false
+		}
+
+		var rowErr *RowError
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				s.err = err
+				return false
+			}
+			rowErr = &RowError{Line: parseErr.Line, Err: parseErr.Err}
+		} else {
+			line, _ := s.reader.FieldPos(0)
+			s.values, rowErr = s.convert(record, line)
+		}
+
+		if rowErr != nil {
+			if !s.skipErrors {
+				s.err = rowErr
+				return false
+			}
+			s.invalid++
+			if len(s.rowErrors) < MaxRowErrorsKept {
+				s.rowErrors = append(s.rowErrors, *rowErr)
+			}
+			if s.maxErrors > 0 && s.invalid > int64(s.maxErrors) {
+				s.err = fmt.Errorf("%w: %w", errTooManyRowErrors, rowErr)
+				return false
+			}
+			continue
+		}
+
+		s.rows++
+		if s.progressInterval > 0 && s.rows%s.progressInterval == 0 {
+			s.report()
+		}
+		return true
+	}
+}
+
+func (s *csvSource) report() {
+	if s.progress != nil {
+		s.progress(s.rows, s.counter.n)
+	}
+}
+
+func (s *csvSource) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *csvSource) Err() error {
+	return s.err
+}
+
+// stringRow loads every field of a record as text.
+func stringRow(fields int) func([]string, int) ([]any, *RowError) {
+	return func(record []string, line int) ([]any, *RowError) {
+		if len(record) != fields {
+			return nil, &RowError{Line: line, Err: fmt.Errorf("%w: %d, expected %d", errBadFieldCount, len(record), fields)}
+		}
+		row := make([]any, len(record))
+		for i := range record {
+			row[i] = record[i]
+		}
+		return row, nil
+	}
+}
+
+// LoadCSV streams CSV data, optionally gzipped, into the table.  The first row must match the column names.  It
+// returns the number of rows loaded.
+func LoadCSV(ctx context.Context, pgxConn *pgxpool.Pool, tableName string, r io.Reader, progress ProgressFunc) (int64, error) {
+	table, err := ParseIdentifier(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	reader, counter, err := openCSV(r)
+	if err != nil {
+		return 0, err
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, errNoRowsFromLoadRow
+		}
+		return 0, fmt.Errorf(errFormat, err)
+	}
+	headers := append([]string(nil), header...)
+
+	source := &csvSource{
+		reader:           reader,
+		counter:          counter,
+		convert:          stringRow(len(headers)),
+		progress:         progress,
+		progressInterval: DefaultProgressInterval,
+	}
+	count, err := pgxConn.CopyFrom(ctx, table, headers, source)
+	if err != nil {
+		return 0, fmt.Errorf(errFormat, err)
+	}
+	if count == 0 {
+		return 0, errNoRowsFromLoadRow
+	}
+	logrus.Info("Loaded ", count, " rows into ", tableName)
+	return count, nil
+}
+
+// LoadCSVFile streams a CSV file, optionally gzipped, into the table.
+func LoadCSVFile(ctx context.Context, pgxConn *pgxpool.Pool, tableName, fileName string, progress ProgressFunc) (int64, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return 0, fmt.Errorf(errFormat, err)
+	}
+	defer file.Close()
+
+	return LoadCSV(ctx, pgxConn, tableName, file, progress)
+}
@@ -0,0 +1,48 @@
+package postgres_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCSV_Gzip(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	if err := postgres.TruncateTable(pgxConn, "test_history"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = fmt.Fprintln(gz, "symbol,date,close")
+	for i := 0; i < 25000; i++ {
+		_, _ = fmt.Fprintf(gz, "S%05d,2024-06-18,%d.25\n", i, i)
+	}
+	assert.Nil(t, gz.Close())
+
+	fileName := filepath.Join(t.TempDir(), "test_history.csv.gz")
+	assert.Nil(t, os.WriteFile(fileName, buf.Bytes(), 0o600))
+
+	var calls []int64
+	count, err := postgres.LoadCSVFile(ctx, pgxConn, "test_history", fileName, func(rows, bytes int64) {
+		calls = append(calls, rows)
+		assert.LessOrEqual(t, bytes, int64(buf.Len()))
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(25000), count)
+	assert.Equal(t, []int64{10000, 20000, 25000}, calls)
+
+	if err = postgres.TruncateTable(pgxConn, "test_history"); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Nil(t, postgres.LoadTableWithHeaders(ctx, pgxConn, "test_history", fileName))
+}
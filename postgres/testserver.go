@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const testServerImage = "postgres:15.3"
+
+// TestServer is a Postgres server that gives each test its own database, cloned from a template with the stock
+// migrations applied.  Tests using different databases can run in parallel, in the same package or in different
+// ones, and nothing is written to the environment.
+type TestServer struct {
+	// URL connects to the server's postgres database as a superuser.
+	URL  string
+	stop func(ctx context.Context) error
+
+	// mu serializes creating databases, which Postgres does not allow concurrently from the same template.
+	mu       sync.Mutex
+	template string
+}
+
+// TestDatabase is a database created for a single test.
+type TestDatabase struct {
+	Name string
+	URL  string
+	Pool *pgxpool.Pool
+}
+
+// randomName returns the prefix followed by random hex digits, a valid unquoted identifier.
+func randomName(prefix string) string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// NewContainerTestServer starts a Postgres container with a unique name.  Call Terminate to stop it.
+func NewContainerTestServer(ctx context.Context) (*TestServer, error) {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        testServerImage,
+			ExposedPorts: []string{"5432/tcp"},
+			Env:          map[string]string{"POSTGRES_USER": "postgres", "POSTGRES_PASSWORD": "postgres"},
+			// The server restarts once after initializing, so wait for it to be ready the second time.
+			WaitingFor: wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+		},
+		Started: true,
+	})
+	if err != nil {
+		logrus.Error("NewContainerTestServer:" + err.Error())
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+	port, err := container.MappedPort(ctx, "5432")
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+
+	server := NewTestServerFromURL(fmt.Sprintf("postgres://postgres:postgres@%s:%s/postgres", host, port.Port()))
+	server.stop = func(ctx context.Context) error { return container.Terminate(ctx) }
+	return server, nil
+}
+
+// NewTestServerFromURL uses a server that is already running.  The URL's user must be able to create databases.
+func NewTestServerFromURL(serverURL string) *TestServer {
+	return &TestServer{URL: serverURL}
+}
+
+// Terminate drops the template database and stops the server if it was started by NewTestServer.
+func (s *TestServer) Terminate(ctx context.Context) error {
+	s.mu.Lock()
+	template := s.template
+	s.template = ""
+	s.mu.Unlock()
+
+	if template != "" {
+		if err := s.dropDatabase(ctx, template); err != nil {
+			logrus.Warn("Terminate:" + err.Error())
+		}
+	}
+	if s.stop == nil {
+		return nil
+	}
+	return s.stop(ctx)
+}
+
+// databaseURL returns the URL of another database on the server.
+func (s *TestServer) databaseURL(name string) (string, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return "", fmt.Errorf(errFormat, err)
+	}
+	u.Path = "/" + name
+	return u.String(), nil
+}
+
+func (s *TestServer) exec(ctx context.Context, sql string) error {
+	conn, err := pgx.Connect(ctx, s.URL)
+	if err != nil {
+		return fmt.Errorf(errFormat, err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, sql); err != nil {
+		return fmt.Errorf(errFormat, err)
+	}
+	return nil
+}
+
+func (s *TestServer) dropDatabase(ctx context.Context, name string) error {
+	return s.exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{name}.Sanitize()+" WITH (FORCE)")
+}
+
+// createTemplate creates and migrates the template database the first time it is needed.  The lock must be held.
+func (s *TestServer) createTemplate(ctx context.Context) error {
+	if s.template != "" {
+		return nil
+	}
+
+	name := randomName("template_")
+	if err := s.exec(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()); err != nil {
+		return err
+	}
+
+	templateURL, err := s.databaseURL(name)
+	if err != nil {
+		return err
+	}
+	pool, err := pgxpool.New(ctx, templateURL)
+	if err != nil {
+		return fmt.Errorf(errFormat, err)
+	}
+	migrator, err := NewStockMigrator(pool)
+	if err == nil {
+		err = migrator.Up(ctx)
+	}
+	// Nothing may be connected to a template while it is copied.
+	pool.Close()
+	if err != nil {
+		_ = s.dropDatabase(ctx, name)
+		return err
+	}
+
+	s.template = name
+	return nil
+}
+
+// CreateDatabase creates a database with the stock tables.  The caller must drop it with DropDatabase.
+func (s *TestServer) CreateDatabase(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.createTemplate(ctx); err != nil {
+		return "", err
+	}
+
+	name := randomName("test_")
+	err := s.exec(ctx, fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", pgx.Identifier{name}.Sanitize(),
+		pgx.Identifier{s.template}.Sanitize()))
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// DropDatabase drops a database, disconnecting anything still connected to it.
+func (s *TestServer) DropDatabase(ctx context.Context, name string) error {
+	return s.dropDatabase(ctx, name)
+}
+
+// Database creates a database with the stock tables for the test and connects to it.  The pool is closed and the
+// database dropped when the test finishes.
+func (s *TestServer) Database(t testing.TB) *TestDatabase {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	name, err := s.CreateDatabase(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() {
+		if err := s.DropDatabase(context.Background(), name); err != nil {
+			t.Error(err.Error())
+		}
+	})
+
+	dbURL, err := s.databaseURL(name)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Cleanups run last in, first out, so the pool is closed before the database is dropped.
+	t.Cleanup(pool.Close)
+
+	return &TestDatabase{Name: name, URL: dbURL, Pool: pool}
+}
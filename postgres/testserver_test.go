@@ -0,0 +1,97 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestServer_Database(t *testing.T) {
+	ctx := context.Background()
+	envURL, envSet := os.LookupEnv("PG_DATABASE_URL")
+
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	t.Run("group", func(t *testing.T) {
+		for _, table := range []string{"first", "second"} {
+			t.Run(table, func(t *testing.T) {
+				t.Parallel()
+				db := testServer.Database(t)
+				mu.Lock()
+				names = append(names, db.Name)
+				mu.Unlock()
+
+				// Each database has the stock tables and only its own changes.
+				_, err := db.Pool.Exec(ctx, "CREATE TABLE "+table+" (id INTEGER); INSERT INTO lookups VALUES ('"+table+"', 'X')")
+				assert.Nil(t, err)
+
+				var count int
+				assert.Nil(t, db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM lookups").Scan(&count))
+				assert.Equal(t, 1, count)
+				assert.Nil(t, db.Pool.QueryRow(ctx,
+					"SELECT COUNT(*) FROM information_schema.tables WHERE table_name IN ('first', 'second')").Scan(&count))
+				assert.Equal(t, 1, count)
+			})
+		}
+	})
+	assert.Len(t, names, 2)
+
+	// The databases are dropped when the tests finish.
+	conn, err := pgx.Connect(ctx, testServer.URL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer conn.Close(ctx)
+	var count int
+	assert.Nil(t, conn.QueryRow(ctx, "SELECT COUNT(*) FROM pg_database WHERE datname = ANY($1)", names).Scan(&count))
+	assert.Equal(t, 0, count)
+
+	after, afterSet := os.LookupEnv("PG_DATABASE_URL")
+	assert.Equal(t, envSet, afterSet)
+	assert.Equal(t, envURL, after)
+}
+
+func TestTestServer_FromURL(t *testing.T) {
+	server := postgres.NewTestServerFromURL(testServer.URL)
+	db := server.Database(t)
+	assert.Nil(t, db.Pool.Ping(context.Background()))
+	t.Cleanup(func() {
+		assert.Nil(t, server.Terminate(context.Background()))
+	})
+}
+
+func TestNewTestServer_UnknownBackend(t *testing.T) {
+	t.Setenv(postgres.TestBackendEnv, "podman")
+	server, err := postgres.NewTestServer(context.Background())
+	assert.Nil(t, server)
+	assert.ErrorContains(t, err, "podman")
+}
+
+func TestNewLocalTestServer(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("postgres can not run as root")
+	}
+	ctx := context.Background()
+	server, err := postgres.NewLocalTestServer(ctx, os.Getenv(postgres.TestBinDirEnv))
+	if errors.Is(err, postgres.ErrPostgresNotFound) {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Registered first so it runs after the database is dropped.
+	t.Cleanup(func() { assert.Nil(t, server.Terminate(ctx)) })
+
+	db := server.Database(t)
+	var count int
+	assert.Nil(t, db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM lookups").Scan(&count))
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultTxRetries is how many times WithTx retries a transaction when TxOptions.Retries is zero.
+	DefaultTxRetries = 3
+	// DefaultTxRetryDelay is the delay before WithTx's first retry when TxOptions.RetryDelay is zero.  It doubles on
+	// each retry.
+	DefaultTxRetryDelay = 50 * time.Millisecond
+
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// Beginner starts transactions.  It is satisfied by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// txBeginner starts transactions with options.  It is satisfied by *pgxpool.Pool and *pgx.Conn, but not pgx.Tx.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions are the options of WithTx.
+type TxOptions struct {
+	// IsoLevel is the isolation level, the server's default when empty.
+	IsoLevel pgx.TxIsoLevel
+	ReadOnly bool
+	// Retries is how many times a transaction that fails with a serialization failure or deadlock is retried.  Zero
+	// uses DefaultTxRetries and a negative value disables retries.
+	Retries int
+	// RetryDelay is the delay before the first retry, DefaultTxRetryDelay when zero.
+	RetryDelay time.Duration
+}
+
+// IsRetryable reports whether err is a serialization failure or a deadlock, meaning the transaction can be retried.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
+
+// WithTx runs fn in a transaction that is committed when fn returns nil and rolled back when it returns an error or
+// panics.  When the transaction fails with a serialization failure or deadlock it is retried with exponential
+// backoff, so fn may run more than once and must not have effects outside the transaction.
+//
+// When db is a pgx.Tx, usually from an enclosing WithTx, fn runs in a savepoint instead.  An error rolls back to the
+// savepoint and is returned to the enclosing transaction, which decides whether to retry; the options are ignored.
+func WithTx(ctx context.Context, db Beginner, opts TxOptions, fn func(pgx.Tx) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return pgx.BeginFunc(ctx, db, fn)
+	}
+
+	txOptions := pgx.TxOptions{IsoLevel: opts.IsoLevel}
+	if opts.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+
+	retries := opts.Retries
+	if retries == 0 {
+		retries = DefaultTxRetries
+	}
+	delay := opts.RetryDelay
+	if delay == 0 {
+		delay = DefaultTxRetryDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := pgx.BeginTxFunc(ctx, beginner, txOptions, fn)
+		if err == nil || !IsRetryable(err) || attempt >= retries {
+			return err
+		}
+
+		// Full jitter keeps transactions that conflicted from retrying in lockstep.
+		wait := time.Duration(rand.Int64N(int64(delay<<attempt)) + 1)
+		logrus.Warn("WithTx: retrying in ", wait, " after ", err.Error())
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func countTxTest(t *testing.T, pgxConn *pgxpool.Pool) int {
+	var count int
+	if err := pgxConn.QueryRow(context.Background(), "SELECT COUNT(*) FROM tx_test").Scan(&count); err != nil {
+		t.Fatal(err.Error())
+	}
+	return count
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	if _, err := pgxConn.Exec(ctx, "DROP TABLE IF EXISTS tx_test; CREATE TABLE tx_test (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// An error rolls everything back.
+	errFailed := errors.New("failed")
+	err := postgres.WithTx(ctx, pgxConn, postgres.TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "INSERT INTO tx_test VALUES (1)"); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, 0, countTxTest(t, pgxConn))
+
+	// A failed nested call only rolls back its savepoint.
+	err = postgres.WithTx(ctx, pgxConn, postgres.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "INSERT INTO tx_test VALUES (1)"); err != nil {
+			return err
+		}
+		nested := postgres.WithTx(ctx, tx, postgres.TxOptions{}, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "INSERT INTO tx_test VALUES (1)")
+			return err
+		})
+		assert.NotNil(t, nested)
+		_, err := tx.Exec(ctx, "INSERT INTO tx_test VALUES (2)")
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, countTxTest(t, pgxConn))
+
+	err = postgres.WithTx(ctx, pgxConn, postgres.TxOptions{ReadOnly: true}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "INSERT INTO tx_test VALUES (3)")
+		return err
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, countTxTest(t, pgxConn))
+}
+
+func TestWithTx_Retry(t *testing.T) {
+	ctx := context.Background()
+	pgxConn := testServer.Database(t).Pool
+
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+	assert.True(t, postgres.IsRetryable(serializationFailure))
+	assert.True(t, postgres.IsRetryable(&pgconn.PgError{Code: "40P01"}))
+	assert.False(t, postgres.IsRetryable(&pgconn.PgError{Code: "23505"}))
+
+	attempts := 0
+	err := postgres.WithTx(ctx, pgxConn, postgres.TxOptions{RetryDelay: time.Millisecond}, func(tx pgx.Tx) error {
+		attempts++
+		if attempts < 3 {
+			return serializationFailure
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = postgres.WithTx(ctx, pgxConn, postgres.TxOptions{Retries: 1, RetryDelay: time.Millisecond}, func(tx pgx.Tx) error {
+		attempts++
+		return serializationFailure
+	})
+	assert.ErrorIs(t, err, serializationFailure)
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	err = postgres.WithTx(ctx, pgxConn, postgres.TxOptions{Retries: -1}, func(tx pgx.Tx) error {
+		attempts++
+		return serializationFailure
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,103 @@
+package settlement
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	businessdays "github.com/kpearce2430/keputils/business-days"
+)
+
+// AssetClass is the kind of security traded, which decides its settlement cycle.
+type AssetClass string
+
+const (
+	Equity       AssetClass = "equity"
+	MutualFund   AssetClass = "mutual_fund"
+	MutualFundT2 AssetClass = "mutual_fund_t2"
+	Treasury     AssetClass = "treasury"
+	Option       AssetClass = "option"
+	FX           AssetClass = "fx"
+)
+
+var errUnknownAssetClass = errors.New("unknown asset class")
+
+// Convention is the settlement cycle of an asset class, T+Days.
+type Convention struct {
+	Days int
+	// TradeCalendar is the calendar the trade executes on.  A trade on a day it is closed rolls to its next business
+	// day.
+	TradeCalendar *businessdays.Calendar
+	// SettlementCalendars are the calendars the settlement days are counted on.  A day counts only when it is a
+	// business day on all of them, e.g. both currencies' calendars for FX.
+	SettlementCalendars []*businessdays.Calendar
+}
+
+// SettlementDate returns the date a trade made on tradeDate settles.
+func (c Convention) SettlementDate(tradeDate time.Time) time.Time {
+	trade := time.Date(tradeDate.Year(), tradeDate.Month(), tradeDate.Day(), 00, 00, 00, 00, time.UTC)
+	if c.TradeCalendar != nil && !c.TradeCalendar.IsBusinessDay(trade) {
+		trade = c.TradeCalendar.NextBusinessDay(trade)
+	}
+	return businessdays.AddJointBusinessDays(trade, c.Days, c.SettlementCalendars...)
+}
+
+// Calculator computes settlement dates from a set of conventions by asset class.
+type Calculator struct {
+	mu          sync.RWMutex
+	conventions map[AssetClass]Convention
+}
+
+// NewCalculator returns a calculator with the US conventions:  equities, mutual funds, treasuries and options settle
+// T+1 and FX (USD/EUR) settles T+2.
+func NewCalculator() *Calculator {
+	nyse := businessdays.DefaultCalendar
+	fed := businessdays.NewFederalReserveCalendar()
+	target := businessdays.NewTARGETCalendar()
+
+	return &Calculator{
+		conventions: map[AssetClass]Convention{
+			Equity:       {Days: 1, TradeCalendar: nyse, SettlementCalendars: []*businessdays.Calendar{nyse, fed}},
+			MutualFund:   {Days: 1, TradeCalendar: nyse, SettlementCalendars: []*businessdays.Calendar{nyse, fed}},
+			MutualFundT2: {Days: 2, TradeCalendar: nyse, SettlementCalendars: []*businessdays.Calendar{nyse, fed}},
+			Treasury:     {Days: 1, TradeCalendar: fed, SettlementCalendars: []*businessdays.Calendar{fed}},
+			Option:       {Days: 1, TradeCalendar: nyse, SettlementCalendars: []*businessdays.Calendar{nyse, fed}},
+			FX:           {Days: 2, TradeCalendar: fed, SettlementCalendars: []*businessdays.Calendar{fed, target}},
+		},
+	}
+}
+
+// Convention returns the convention for the asset class.
+func (c *Calculator) Convention(assetClass AssetClass) (Convention, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	convention, ok := c.conventions[assetClass]
+	return convention, ok
+}
+
+// SetConvention adds or replaces the convention for an asset class, e.g. another currency pair.
+func (c *Calculator) SetConvention(assetClass AssetClass, convention Convention) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conventions == nil {
+		c.conventions = make(map[AssetClass]Convention)
+	}
+	c.conventions[assetClass] = convention
+}
+
+// SettlementDate returns the date a trade of the asset class made on tradeDate is expected to settle.
+func (c *Calculator) SettlementDate(assetClass AssetClass, tradeDate time.Time) (time.Time, error) {
+	convention, ok := c.Convention(assetClass)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: %s", errUnknownAssetClass, assetClass)
+	}
+	return convention.SettlementDate(tradeDate), nil
+}
+
+var defaultCalculator = NewCalculator()
+
+// SettlementDate returns the settlement date using the default US conventions.
+func SettlementDate(assetClass AssetClass, tradeDate time.Time) (time.Time, error) {
+	return defaultCalculator.SettlementDate(assetClass, tradeDate)
+}
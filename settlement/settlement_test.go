@@ -0,0 +1,74 @@
+package settlement_test
+
+import (
+	"testing"
+	"time"
+
+	businessdays "github.com/kpearce2430/keputils/business-days"
+	"github.com/kpearce2430/keputils/settlement"
+)
+
+func TestSettlementDate(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		Name       string
+		AssetClass settlement.AssetClass
+		Trade      time.Time
+		Expected   time.Time
+	}
+
+	tests := []testCase{
+		{Name: "Equity Monday", AssetClass: settlement.Equity, Trade: time.Date(2024, 6, 10, 14, 30, 0, 0, time.UTC), Expected: time.Date(2024, 6, 11, 0, 0, 0, 0, time.UTC)},
+		{Name: "Equity Friday", AssetClass: settlement.Equity, Trade: time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC), Expected: time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC)},
+		{Name: "Equity Before Juneteenth", AssetClass: settlement.Equity, Trade: time.Date(2024, 6, 18, 0, 0, 0, 0, time.UTC), Expected: time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)},
+		{Name: "Equity Columbus Day", AssetClass: settlement.Equity, Trade: time.Date(2024, 10, 11, 0, 0, 0, 0, time.UTC), Expected: time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{Name: "Equity Traded On Holiday", AssetClass: settlement.Equity, Trade: time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC), Expected: time.Date(2024, 7, 8, 0, 0, 0, 0, time.UTC)},
+		{Name: "Mutual Fund T+2", AssetClass: settlement.MutualFundT2, Trade: time.Date(2024, 6, 13, 0, 0, 0, 0, time.UTC), Expected: time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC)},
+		{Name: "Treasury Good Friday", AssetClass: settlement.Treasury, Trade: time.Date(2024, 3, 28, 0, 0, 0, 0, time.UTC), Expected: time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)},
+		{Name: "FX Easter Monday", AssetClass: settlement.FX, Trade: time.Date(2024, 3, 28, 0, 0, 0, 0, time.UTC), Expected: time.Date(2024, 4, 3, 0, 0, 0, 0, time.UTC)},
+		{Name: "FX Veterans Day", AssetClass: settlement.FX, Trade: time.Date(2024, 11, 7, 0, 0, 0, 0, time.UTC), Expected: time.Date(2024, 11, 12, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			result, err := settlement.SettlementDate(tc.AssetClass, tc.Trade)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if !result.Equal(tc.Expected) {
+				t.Log("Test:", tc.Name, "Failed:", result)
+				t.Fail()
+			}
+		})
+	}
+
+	if _, err := settlement.SettlementDate("crypto", time.Now()); err == nil {
+		t.Error("expected an unknown asset class error")
+	}
+}
+
+func TestCalculator_SetConvention(t *testing.T) {
+	t.Parallel()
+	calculator := settlement.NewCalculator()
+	convention, ok := calculator.Convention(settlement.MutualFund)
+	if !ok {
+		t.Fatal("missing mutual fund convention")
+	}
+
+	convention.Days = 3
+	calculator.SetConvention("fund_t3", convention)
+	result, err := calculator.SettlementDate("fund_t3", time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !result.Equal(time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Bad settlement date:", result)
+	}
+
+	calculator.SetConvention("no_calendar", settlement.Convention{Days: 1, TradeCalendar: businessdays.NewCalendar("empty", nil, nil)})
+	result, _ = calculator.SettlementDate("no_calendar", time.Date(2024, 7, 3, 0, 0, 0, 0, time.UTC))
+	if !result.Equal(time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Bad settlement date:", result)
+	}
+}
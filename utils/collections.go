@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Map returns the result of f for each item of s, in order.
+func Map[T, U any](s []T, f func(T) U) []U {
+	result := make([]U, len(s))
+	for i, item := range s {
+		result[i] = f(item)
+	}
+	return result
+}
+
+// Filter returns the items of s that keep returns true for, in order.
+func Filter[T any](s []T, keep func(T) bool) []T {
+	var result []T
+	for _, item := range s {
+		if keep(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Partition returns the items of s that match returns true for and those it returns false for, in order.
+func Partition[T any](s []T, match func(T) bool) (matched, rest []T) {
+	for _, item := range s {
+		if match(item) {
+			matched = append(matched, item)
+		} else {
+			rest = append(rest, item)
+		}
+	}
+	return matched, rest
+}
+
+// GroupBy returns the items of s by their key, in order within each group.
+func GroupBy[T any, K comparable](s []T, key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, item := range s {
+		k := key(item)
+		groups[k] = append(groups[k], item)
+	}
+	return groups
+}
+
+// Chunk splits s into slices of size items, the last may be shorter.  The chunks share s's memory but appending to one
+// does not overwrite the next.  It panics if size is less than 1.
+func Chunk[T any](s []T, size int) [][]T {
+	if size < 1 {
+		panic("utils.Chunk: size must be positive")
+	}
+	chunks := make([][]T, 0, (len(s)+size-1)/size)
+	for start := 0; start < len(s); start += size {
+		end := min(start+size, len(s))
+		chunks = append(chunks, s[start:end:end])
+	}
+	return chunks
+}
+
+// Uniq returns the items of s without duplicates, keeping the first of each.
+func Uniq[T comparable](s []T) []T {
+	seen := make(Set[T], len(s))
+	var result []T
+	for _, item := range s {
+		if !seen.Has(item) {
+			seen.Add(item)
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// SortedKeys returns the keys of the map, or the items of a Set, in ascending order.
+func SortedKeys[M ~map[K]V, K cmp.Ordered, V any](m M) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
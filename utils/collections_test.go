@@ -0,0 +1,78 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type holding struct {
+	Account string
+	Symbol  string
+	Shares  int
+}
+
+var holdings = []holding{
+	{Account: "IRA", Symbol: "HD", Shares: 10},
+	{Account: "Brokerage", Symbol: "AAPL", Shares: 5},
+	{Account: "IRA", Symbol: "MSFT", Shares: 0},
+	{Account: "Brokerage", Symbol: "HD", Shares: 3},
+}
+
+func TestContainsGeneric(t *testing.T) {
+	assert.True(t, utils.Contains([]int{1, 2, 3}, 2))
+	assert.False(t, utils.Contains([]int{1, 2, 3}, 4))
+	assert.False(t, utils.Contains(nil, "a"))
+}
+
+func TestSet(t *testing.T) {
+	ira := utils.NewSet("HD", "MSFT")
+	brokerage := utils.NewSet("AAPL", "HD")
+
+	assert.True(t, ira.Has("HD"))
+	assert.False(t, ira.Has("AAPL"))
+	assert.Equal(t, []string{"AAPL", "HD", "MSFT"}, utils.SortedKeys(ira.Union(brokerage)))
+	assert.Equal(t, []string{"HD"}, utils.SortedKeys(ira.Intersection(brokerage)))
+	assert.Equal(t, []string{"MSFT"}, utils.SortedKeys(ira.Difference(brokerage)))
+	assert.True(t, utils.NewSet("HD").IsSubset(ira))
+	assert.False(t, ira.IsSubset(brokerage))
+	assert.True(t, ira.Equal(utils.NewSet("MSFT", "HD", "HD")))
+
+	ira.Add("T")
+	ira.Remove("MSFT", "missing")
+	assert.Equal(t, 2, ira.Len())
+	assert.ElementsMatch(t, []string{"HD", "T"}, ira.Items())
+
+	var empty utils.Set[string]
+	assert.False(t, empty.Has("HD"))
+	assert.Equal(t, 0, empty.Union(nil).Len())
+}
+
+func TestSliceHelpers(t *testing.T) {
+	symbols := utils.Map(holdings, func(h holding) string { return h.Symbol })
+	assert.Equal(t, []string{"HD", "AAPL", "MSFT", "HD"}, symbols)
+	assert.Equal(t, []string{"HD", "AAPL", "MSFT"}, utils.Uniq(symbols))
+
+	held := utils.Filter(holdings, func(h holding) bool { return h.Shares > 0 })
+	assert.Len(t, held, 3)
+	assert.Nil(t, utils.Filter(holdings, func(h holding) bool { return false }))
+
+	ira, other := utils.Partition(holdings, func(h holding) bool { return h.Account == "IRA" })
+	assert.Equal(t, []holding{holdings[0], holdings[2]}, ira)
+	assert.Equal(t, []holding{holdings[1], holdings[3]}, other)
+
+	byAccount := utils.GroupBy(holdings, func(h holding) string { return h.Account })
+	assert.Equal(t, []string{"Brokerage", "IRA"}, utils.SortedKeys(byAccount))
+	assert.Equal(t, []holding{holdings[1], holdings[3]}, byAccount["Brokerage"])
+
+	chunks := utils.Chunk([]int{1, 2, 3, 4, 5}, 2)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, chunks)
+	chunks[0] = append(chunks[0], 99)
+	assert.Equal(t, []int{3, 4}, chunks[1])
+	assert.Empty(t, utils.Chunk([]int{}, 3))
+	assert.Panics(t, func() { utils.Chunk([]int{1}, 0) })
+
+	assert.Equal(t, []string{"A", "B"}, utils.Map([]string{"a", "b"}, strings.ToUpper))
+}
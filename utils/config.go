@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secretFileSuffix is added to a key to read its value from a file, as Docker and Kubernetes mount secrets.
+const secretFileSuffix = "_FILE"
+
+var (
+	errMissingConfig = errors.New("is required")
+	errBadDotEnv     = errors.New("invalid .env line")
+	// secretWords mark keys whose values are redacted by Dump.
+	secretWords = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "CREDENTIAL", "PRIVATE", "API_KEY"}
+)
+
+// configValue is a value that was read, for Dump.
+type configValue struct {
+	value  string
+	source string
+	secret bool
+}
+
+// Config reads typed settings from the environment, files named by KEY_FILE variables and .env files.  Each key is
+// looked up with the prefix first, e.g. MYAPP_KEY, then without it, the same as envconfig.  For each of those names
+// an environment variable wins over a NAME_FILE variable, which wins over a .env file.
+//
+// Getters return the default when a key is not set and record values that can not be parsed, Err returns every
+// problem at once.
+type Config struct {
+	prefix string
+	lookup func(string) (string, bool)
+	dotEnv map[string]string
+
+	errs   []error
+	values map[string]configValue
+	// badFiles are the KEY_FILE variables whose files could not be read, so each is only reported once.
+	badFiles map[string]bool
+}
+
+// NewConfig reads the environment.  When prefix is empty the PREFIX environment variable is used.
+func NewConfig(prefix string) *Config {
+	if prefix == "" {
+		prefix = GetEnv("PREFIX", "")
+	}
+	return newConfig(prefix, os.LookupEnv)
+}
+
+// NewConfigFromMap reads the map instead of the environment, for tests.
+func NewConfigFromMap(prefix string, env map[string]string) *Config {
+	return newConfig(prefix, func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+}
+
+func newConfig(prefix string, lookup func(string) (string, bool)) *Config {
+	return &Config{
+		prefix:   prefix,
+		lookup:   lookup,
+		dotEnv:   make(map[string]string),
+		values:   make(map[string]configValue),
+		badFiles: make(map[string]bool),
+	}
+}
+
+// LoadDotEnv reads KEY=VALUE lines from a .env file.  Environment variables win over its values.  A missing file is
+// not an error, so the same code runs where the environment is set another way.
+func (c *Config) LoadDotEnv(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		key, value, ok, err := parseDotEnvLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if ok {
+			c.dotEnv[key] = value
+		}
+	}
+	return scanner.Err()
+}
+
+// parseDotEnvLine parses KEY=VALUE, optionally after "export".  Double quoted values may use escapes like \n, single
+// quoted values are taken as is, and unquoted values end at a " #" comment.  ok is false for blank and comment lines.
+func parseDotEnvLine(line string) (key, value string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+	line = strings.TrimPrefix(line, "export ")
+
+	key, value, found := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !found || key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false, fmt.Errorf("%w: %q", errBadDotEnv, line)
+	}
+
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		if value, err = strconv.Unquote(value); err != nil {
+			return "", "", false, fmt.Errorf("%w: %q", errBadDotEnv, line)
+		}
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		value = value[1 : len(value)-1]
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	return key, value, true, nil
+}
+
+// names returns the names a key is looked up by.
+func (c *Config) names(key string) []string {
+	if c.prefix == "" {
+		return []string{key}
+	}
+	return []string{c.prefix + "_" + key, key}
+}
+
+// raw returns the value of the key and the name it was found by.
+func (c *Config) raw(key string) (name, value, source string, ok bool) {
+	for _, name := range c.names(key) {
+		if value, ok := c.lookup(name); ok {
+			return name, value, "env", true
+		}
+		if path, ok := c.lookup(name + secretFileSuffix); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				if !c.badFiles[name] {
+					c.badFiles[name] = true
+					c.errs = append(c.errs, fmt.Errorf("%s: %w", name+secretFileSuffix, err))
+				}
+				continue
+			}
+			return name, strings.TrimRight(string(data), "\r\n"), "file " + path, true
+		}
+		if value, ok := c.dotEnv[name]; ok {
+			return name, value, ".env", true
+		}
+	}
+	return key, "", "", false
+}
+
+// get returns the key's value, recording it for Dump.  Values read from files are secret.
+func (c *Config) get(key, def string, secret bool) (string, bool) {
+	name, value, source, ok := c.raw(key)
+	if !ok {
+		value, source = def, "default"
+	}
+	secret = secret || isSecretKey(key) || strings.HasPrefix(source, "file ")
+	c.values[name] = configValue{value: value, source: source, secret: secret}
+	return value, ok
+}
+
+func isSecretKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, word := range secretWords {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) invalid(key, value string, err error) {
+	c.errs = append(c.errs, fmt.Errorf("%s: invalid value %q: %w", key, value, err))
+}
+
+// Required records an error for each key that is not set.
+func (c *Config) Required(keys ...string) {
+	for _, key := range keys {
+		if _, _, _, ok := c.raw(key); !ok {
+			c.errs = append(c.errs, fmt.Errorf("%s %w", strings.Join(c.names(key), " or "), errMissingConfig))
+		}
+	}
+}
+
+// String returns the key's value, or def when it is not set.
+func (c *Config) String(key, def string) string {
+	value, _ := c.get(key, def, false)
+	return value
+}
+
+// Secret is String for a value Dump must not show.  Keys naming passwords, tokens and the like are secret anyway.
+func (c *Config) Secret(key, def string) string {
+	value, _ := c.get(key, def, true)
+	return value
+}
+
+// Int returns the key's value as an int.
+func (c *Config) Int(key string, def int) int {
+	value, ok := c.get(key, strconv.Itoa(def), false)
+	if !ok {
+		return def
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		c.invalid(key, value, err)
+		return def
+	}
+	return i
+}
+
+// Bool returns the key's value as a bool, which may also be yes/no or on/off.
+func (c *Config) Bool(key string, def bool) bool {
+	value, ok := c.get(key, strconv.FormatBool(def), false)
+	if !ok {
+		return def
+	}
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "y", "on":
+		return true
+	case "no", "n", "off":
+		return false
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		c.invalid(key, value, err)
+		return def
+	}
+	return b
+}
+
+// Duration returns the key's value as a time.Duration, like "30s" or "1h15m".
+func (c *Config) Duration(key string, def time.Duration) time.Duration {
+	value, ok := c.get(key, def.String(), false)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		c.invalid(key, value, err)
+		return def
+	}
+	return d
+}
+
+// List returns the key's comma separated values, without surrounding space or empty values.
+func (c *Config) List(key string, def []string) []string {
+	value, ok := c.get(key, strings.Join(def, ","), false)
+	if !ok {
+		return def
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Err returns the missing and invalid keys, nil when there are none.
+func (c *Config) Err() error {
+	return errors.Join(c.errs...)
+}
+
+// Dump returns the values read so far, one NAME=value line each in name order with where it came from, for startup
+// logs.  Secret values are replaced by asterisks.
+func (c *Config) Dump() string {
+	names := make([]string, 0, len(c.values))
+	for name := range c.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		v := c.values[name]
+		value := v.value
+		if v.secret && value != "" {
+			value = "********"
+		}
+		fmt.Fprintf(&b, "%s=%s (%s)\n", name, value, v.source)
+	}
+	return b.String()
+}
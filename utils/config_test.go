@@ -0,0 +1,106 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Getters(t *testing.T) {
+	cfg := utils.NewConfigFromMap("APP", map[string]string{
+		"APP_PORT":   "8080",
+		"PORT":       "9090",
+		"DEBUG":      "yes",
+		"TIMEOUT":    "1m30s",
+		"HOSTS":      " a, b ,,c ",
+		"RETRIES":    "three",
+		"API_TOKEN":  "abc123",
+		"EMPTY_LIST": "",
+	})
+
+	assert.Equal(t, 8080, cfg.Int("PORT", 1))
+	assert.Equal(t, true, cfg.Bool("DEBUG", false))
+	assert.Equal(t, 90*time.Second, cfg.Duration("TIMEOUT", time.Second))
+	assert.Equal(t, []string{"a", "b", "c"}, cfg.List("HOSTS", nil))
+	assert.Nil(t, cfg.List("EMPTY_LIST", []string{"x"}))
+	assert.Equal(t, "localhost", cfg.String("HOST", "localhost"))
+	assert.Equal(t, 5, cfg.Int("MISSING", 5))
+	assert.Nil(t, cfg.Err())
+
+	assert.Equal(t, 3, cfg.Int("RETRIES", 3))
+	assert.Equal(t, "abc123", cfg.String("API_TOKEN", ""))
+	cfg.Required("NAME", "PORT", "DATABASE")
+	err := cfg.Err()
+	assert.ErrorContains(t, err, `RETRIES: invalid value "three"`)
+	assert.ErrorContains(t, err, "APP_NAME or NAME is required")
+	assert.ErrorContains(t, err, "APP_DATABASE or DATABASE is required")
+	assert.NotContains(t, err.Error(), "PORT is required")
+
+	dump := cfg.Dump()
+	assert.Contains(t, dump, "APP_PORT=8080 (env)\n")
+	assert.Contains(t, dump, "HOST=localhost (default)\n")
+	assert.Contains(t, dump, "API_TOKEN=******** (env)\n")
+	assert.NotContains(t, dump, "abc123")
+}
+
+func TestConfig_FilesAndDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "password")
+	assert.Nil(t, os.WriteFile(secret, []byte("s3cret\n"), 0o600))
+
+	dotEnv := filepath.Join(dir, ".env")
+	assert.Nil(t, os.WriteFile(dotEnv, []byte(`# settings
+export DB_USER=admin
+DB_HOST = db.local # the host
+DB_NAME="my db\tname"
+DB_NOTE='not #a comment'
+DB_PORT=5432
+`), 0o600))
+
+	cfg := utils.NewConfigFromMap("", map[string]string{
+		"DB_PASSWORD_FILE": secret,
+		"DB_PORT":          "6543",
+		"DB_KEY_FILE":      filepath.Join(dir, "missing"),
+	})
+	assert.Nil(t, cfg.LoadDotEnv(dotEnv))
+	assert.Nil(t, cfg.LoadDotEnv(filepath.Join(dir, "none.env")))
+
+	assert.Equal(t, "s3cret", cfg.String("DB_PASSWORD", ""))
+	assert.Equal(t, "admin", cfg.String("DB_USER", ""))
+	assert.Equal(t, "db.local", cfg.String("DB_HOST", ""))
+	assert.Equal(t, "my db\tname", cfg.String("DB_NAME", ""))
+	assert.Equal(t, "not #a comment", cfg.String("DB_NOTE", ""))
+	// The environment wins over the .env file.
+	assert.Equal(t, 6543, cfg.Int("DB_PORT", 0))
+	assert.Equal(t, "default", cfg.Secret("DB_KEY", "default"))
+	assert.ErrorContains(t, cfg.Err(), "DB_KEY_FILE")
+
+	dump := cfg.Dump()
+	assert.Contains(t, dump, "DB_PASSWORD=******** (file "+secret+")\n")
+	assert.Contains(t, dump, "DB_USER=admin (.env)\n")
+	assert.Contains(t, dump, "DB_KEY=******** (default)\n")
+
+	// A file that can not be read is reported once, however often the key is read.
+	missing := utils.NewConfigFromMap("", map[string]string{"API_TOKEN_FILE": filepath.Join(dir, "no-such-file")})
+	missing.Required("API_TOKEN")
+	assert.Equal(t, "", missing.Secret("API_TOKEN", ""))
+	if assert.NotNil(t, missing.Err()) {
+		assert.Equal(t, 1, strings.Count(missing.Err().Error(), "API_TOKEN_FILE"))
+		assert.ErrorIs(t, missing.Err(), os.ErrNotExist)
+	}
+
+	bad := filepath.Join(dir, "bad.env")
+	assert.Nil(t, os.WriteFile(bad, []byte("GOOD=1\nNOT A SETTING\n"), 0o600))
+	assert.ErrorContains(t, utils.NewConfigFromMap("", nil).LoadDotEnv(bad), "bad.env:2")
+}
+
+func TestNewConfig_Prefix(t *testing.T) {
+	t.Setenv("PREFIX", "SVC")
+	t.Setenv("SVC_CONFIG_TEST_NAME", "prefixed")
+	assert.Equal(t, "prefixed", utils.NewConfig("").String("CONFIG_TEST_NAME", ""))
+}
@@ -1,13 +1,8 @@
 package utils
 
-import "github.com/sirupsen/logrus"
+import "slices"
 
-func Contains(s []string, str string) bool {
-	for _, v := range s {
-		if v == str {
-			logrus.Debug("contains ", str, " in ", s)
-			return true
-		}
-	}
-	return false
+// Contains reports whether v is in s.
+func Contains[T comparable](s []T, v T) bool {
+	return slices.Contains(s, v)
 }
@@ -0,0 +1,449 @@
+package utils
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxDecimalExponent limits exponents like 1e1000000 which would allocate huge numbers.
+const maxDecimalExponent = 10000
+
+var (
+	errBadDecimal     = errors.New("invalid decimal")
+	errNullDecimal    = errors.New("can not scan NULL into Decimal, use NullDecimal")
+	errDivideByZero   = errors.New("decimal division by zero")
+	amountReplacer    = strings.NewReplacer(",", "", "$", "", "#", "", "%", "", " ", "")
+	bigTen            = big.NewInt(10)
+	decimalNullTokens = []string{"", "N/A", "Add"}
+)
+
+// RoundingMode is how a Decimal is rounded to fewer places.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds to the nearest, and halves away from zero.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds to the nearest, and halves to the even digit, which does not bias sums.
+	RoundHalfEven
+	// RoundHalfDown rounds to the nearest, and halves toward zero.
+	RoundHalfDown
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundDown rounds toward zero, truncating.
+	RoundDown
+	// RoundCeiling rounds toward positive infinity.
+	RoundCeiling
+	// RoundFloor rounds toward negative infinity.
+	RoundFloor
+)
+
+// Decimal is an exact decimal number, an arbitrary precision integer and the number of places after the decimal point.
+// The zero value is 0.  Decimals are immutable, the arithmetic methods return new values.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+// pow10 returns 10 to the n.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// newDecimal returns coef scaled by places, which may be negative.  It takes ownership of coef.
+func newDecimal(coef *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// NewDecimal returns unscaled divided by 10 to the scale, so NewDecimal(12345, 2) is 123.45.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(unscaled), scale)
+}
+
+// DecimalFromInt returns the whole number.
+func DecimalFromInt(i int64) Decimal {
+	return NewDecimal(i, 0)
+}
+
+// DecimalFromFloat returns the shortest decimal that converts back to the float, so 0.1 is 0.1 and not the float's
+// exact binary value.  NaN and infinities are an error.
+func DecimalFromFloat(f float64) (Decimal, error) {
+	return parseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// ParseDecimal parses a plain number, like strconv.ParseFloat, without rounding it.  It takes an optional sign,
+// digits with an optional decimal point, and an optional exponent.
+func ParseDecimal(s string) (Decimal, error) {
+	return parseDecimal(strings.TrimSpace(s))
+}
+
+// ParseAmount parses the same inputs as FloatParse exactly: currency, grouping and percent characters are ignored,
+// and "", "N/A" and "Add" are zero.
+func ParseAmount(s string) (Decimal, error) {
+	t := amountReplacer.Replace(strings.TrimSpace(s))
+	if Contains(decimalNullTokens, t) {
+		return Decimal{}, nil
+	}
+	return parseDecimal(t)
+}
+
+// MustParseDecimal is ParseDecimal for constants, it panics if s is invalid.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func parseDecimal(s string) (Decimal, error) {
+	mantissa, exponent, hasExponent := s, "", false
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa, exponent, hasExponent = s[:i], s[i+1:], true
+	}
+
+	negative := false
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		negative = mantissa[0] == '-'
+		mantissa = mantissa[1:]
+	}
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	digits := whole + fraction
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("%w: %q", errBadDecimal, s)
+	}
+
+	scale := int64(len(fraction))
+	if hasExponent {
+		// The exponent is an optional sign and at least one digit, ParseInt rejects anything else.
+		e, err := strconv.ParseInt(exponent, 10, 32)
+		if err != nil || e > maxDecimalExponent || e < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("%w: %q", errBadDecimal, s)
+		}
+		scale -= e
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		coef.Neg(coef)
+	}
+	return newDecimal(coef, int32(scale)), nil
+}
+
+// int returns the unscaled value, which must not be modified.
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// rescaled returns the unscaled value at a larger scale.
+func (d Decimal) rescaled(scale int32) *big.Int {
+	coef := new(big.Int).Set(d.int())
+	if scale > d.scale {
+		coef.Mul(coef, pow10(scale-d.scale))
+	}
+	return coef
+}
+
+// Scale returns the number of places after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or 1.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp returns -1, 0 or 1 as d is less than, equal to or greater than e.  1.50 and 1.5 are equal.
+func (d Decimal) Cmp(e Decimal) int {
+	scale := max(d.scale, e.scale)
+	return d.rescaled(scale).Cmp(e.rescaled(scale))
+}
+
+// Equal reports whether d and e are the same number.
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Add returns d + e, with the larger of their scales.
+func (d Decimal) Add(e Decimal) Decimal {
+	scale := max(d.scale, e.scale)
+	return Decimal{coef: d.rescaled(scale).Add(d.rescaled(scale), e.rescaled(scale)), scale: scale}
+}
+
+// Sub returns d - e, with the larger of their scales.
+func (d Decimal) Sub(e Decimal) Decimal {
+	return d.Add(e.Neg())
+}
+
+// Mul returns d * e exactly, its scale is the sum of theirs.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), e.int()), scale: d.scale + e.scale}
+}
+
+// Div returns d / e rounded to scale places, at least 0.
+func (d Decimal) Div(e Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	scale = max(scale, 0)
+	if e.IsZero() {
+		return Decimal{}, errDivideByZero
+	}
+
+	// d / e = (dc / 10^ds) / (ec / 10^es), scaled up by 10^scale.
+	n := new(big.Int).Set(d.int())
+	m := new(big.Int).Set(e.int())
+	if shift := scale + e.scale - d.scale; shift >= 0 {
+		n.Mul(n, pow10(shift))
+	} else {
+		m.Mul(m, pow10(-shift))
+	}
+	return Decimal{coef: roundQuo(n, m, mode), scale: scale}, nil
+}
+
+// roundQuo returns n / m rounded.
+func roundQuo(n, m *big.Int, mode RoundingMode) *big.Int {
+	if m.Sign() < 0 {
+		n, m = new(big.Int).Neg(n), new(big.Int).Neg(m)
+	}
+	q, r := new(big.Int).QuoRem(n, m, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// half compares the remainder to half the divisor.
+	twice := new(big.Int).Abs(r)
+	half := twice.Lsh(twice, 1).Cmp(m)
+	away := false
+	switch mode {
+	case RoundHalfUp:
+		away = half >= 0
+	case RoundHalfEven:
+		away = half > 0 || half == 0 && q.Bit(0) == 1
+	case RoundHalfDown:
+		away = half > 0
+	case RoundUp:
+		away = true
+	case RoundDown:
+	case RoundCeiling:
+		away = n.Sign() > 0
+	case RoundFloor:
+		away = n.Sign() < 0
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+	return q
+}
+
+// Round returns d with exactly places digits after the decimal point, rounding or adding zeros as needed.  Negative
+// places are 0.
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	places = max(places, 0)
+	if places >= d.scale {
+		return Decimal{coef: d.rescaled(places), scale: places}
+	}
+	return Decimal{coef: roundQuo(d.int(), pow10(d.scale-places), mode), scale: places}
+}
+
+// String returns d with its scale's digits after the decimal point and no exponent, like "-1234.50".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// StringFixed returns d rounded half up to places digits after the decimal point.
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places, RoundHalfUp).String()
+}
+
+// Float64 returns the nearest float64, for display and statistics where exactness does not matter.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Rat returns d as a fraction.
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.int(), pow10(d.scale))
+}
+
+// MarshalJSON writes d as a JSON number with all its digits.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or a string holding one.  null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	value, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = value
+	return nil
+}
+
+// Scan implements sql.Scanner for numbers returned as text, integers or floats.
+func (d *Decimal) Scan(src any) error {
+	var (
+		value Decimal
+		err   error
+	)
+	switch v := src.(type) {
+	case nil:
+		return errNullDecimal
+	case string:
+		value, err = ParseDecimal(v)
+	case []byte:
+		value, err = ParseDecimal(string(v))
+	case int64:
+		value = DecimalFromInt(v)
+	case float64:
+		value, err = DecimalFromFloat(v)
+	default:
+		return fmt.Errorf("%w: can not scan %T", errBadDecimal, src)
+	}
+	if err != nil {
+		return err
+	}
+	*d = value
+	return nil
+}
+
+// Value implements driver.Valuer, it sends d as text.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// ScanNumeric implements pgtype.NumericScanner, so pgx scans NUMERIC columns into a Decimal without a float.
+func (d *Decimal) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return errNullDecimal
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: NaN or infinity", errBadDecimal)
+	}
+	coef := new(big.Int)
+	if n.Int != nil {
+		coef.Set(n.Int)
+	}
+	*d = newDecimal(coef, -n.Exp)
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer, so pgx sends a Decimal as a NUMERIC.
+func (d Decimal) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: new(big.Int).Set(d.int()), Exp: -d.scale, Valid: true}, nil
+}
+
+// NullDecimal is a Decimal that may be NULL.
+type NullDecimal struct {
+	Decimal Decimal
+	Valid   bool
+}
+
+// MarshalJSON writes null when n is not valid.
+func (n NullDecimal) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return n.Decimal.MarshalJSON()
+}
+
+// UnmarshalJSON reads a number, a string holding one, or null.
+func (n *NullDecimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = NullDecimal{}
+		return nil
+	}
+	if err := n.Decimal.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner.
+func (n *NullDecimal) Scan(src any) error {
+	if src == nil {
+		*n = NullDecimal{}
+		return nil
+	}
+	if err := n.Decimal.Scan(src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Decimal.Value()
+}
+
+// ScanNumeric implements pgtype.NumericScanner.
+func (n *NullDecimal) ScanNumeric(v pgtype.Numeric) error {
+	if !v.Valid {
+		*n = NullDecimal{}
+		return nil
+	}
+	if err := n.Decimal.ScanNumeric(v); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer.
+func (n NullDecimal) NumericValue() (pgtype.Numeric, error) {
+	if !n.Valid {
+		return pgtype.Numeric{}, nil
+	}
+	return n.Decimal.NumericValue()
+}
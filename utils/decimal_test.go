@@ -0,0 +1,206 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		Description string
+		Input       string
+		Expected    string
+		Error       bool
+	}{
+		{Description: "Float 1.00", Input: "1.00", Expected: "1.00"},
+		{Description: "One Dollar", Input: "$1.00", Expected: "1.00"},
+		{Description: "Thousand 99", Input: "$1,000.99", Expected: "1000.99"},
+		{Description: "Negative", Input: "-$0.05", Expected: "-0.05"},
+		{Description: "Percent", Input: "12.5%", Expected: "12.5"},
+		{Description: "Exponent", Input: "1.5e3", Expected: "1500"},
+		{Description: "Small Exponent", Input: "15E-4", Expected: "0.0015"},
+		{Description: "N/A", Input: "N/A", Expected: "0"},
+		{Description: "Empty", Input: "", Expected: "0"},
+		{Description: "Some Junk", Input: "Junk", Error: true},
+		{Description: "Two Points", Input: "1.2.3", Error: true},
+		{Description: "Huge Exponent", Input: "1e100000", Error: true},
+		{Description: "Empty Exponent", Input: "1e", Error: true},
+		{Description: "Sign Only Exponent", Input: "1e+", Error: true},
+		{Description: "Empty Upper Exponent", Input: "2E", Error: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Description, func(t *testing.T) {
+			result, err := utils.ParseAmount(tc.Input)
+			if tc.Error {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Expected, result.String())
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	// 0.1 added ten times is exactly 1, which it is not as a float.
+	sum := utils.Decimal{}
+	for range 10 {
+		sum = sum.Add(utils.MustParseDecimal("0.1"))
+	}
+	assert.True(t, sum.Equal(utils.DecimalFromInt(1)))
+	assert.Equal(t, "1.0", sum.String())
+
+	price := utils.MustParseDecimal("345.67")
+	shares := utils.MustParseDecimal("10.125")
+	assert.Equal(t, "3499.90875", price.Mul(shares).String())
+	assert.Equal(t, "335.545", price.Sub(shares).String())
+	assert.Equal(t, 1, price.Cmp(shares))
+	assert.Equal(t, "-345.67", price.Neg().String())
+	assert.Equal(t, "345.67", price.Neg().Abs().String())
+
+	quotient, err := utils.DecimalFromInt(1).Div(utils.DecimalFromInt(3), 4, utils.RoundHalfUp)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.3333", quotient.String())
+	quotient, err = utils.DecimalFromInt(-2).Div(utils.DecimalFromInt(3), 2, utils.RoundHalfUp)
+	assert.Nil(t, err)
+	assert.Equal(t, "-0.67", quotient.String())
+	_, err = price.Div(utils.Decimal{}, 2, utils.RoundHalfUp)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "0.001", utils.NewDecimal(1, 3).String())
+	assert.Equal(t, "1200", utils.NewDecimal(12, -2).String())
+	f, err := utils.DecimalFromFloat(0.1)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1", f.String())
+	assert.Equal(t, 345.67, price.Float64())
+}
+
+func TestDecimal_Round(t *testing.T) {
+	tests := []struct {
+		Mode     utils.RoundingMode
+		Expected []string
+	}{
+		{Mode: utils.RoundHalfUp, Expected: []string{"2.5", "2.6", "-2.6", "2.4"}},
+		{Mode: utils.RoundHalfEven, Expected: []string{"2.4", "2.6", "-2.6", "2.4"}},
+		{Mode: utils.RoundHalfDown, Expected: []string{"2.4", "2.5", "-2.5", "2.4"}},
+		{Mode: utils.RoundUp, Expected: []string{"2.5", "2.6", "-2.6", "2.5"}},
+		{Mode: utils.RoundDown, Expected: []string{"2.4", "2.5", "-2.5", "2.4"}},
+		{Mode: utils.RoundCeiling, Expected: []string{"2.5", "2.6", "-2.5", "2.5"}},
+		{Mode: utils.RoundFloor, Expected: []string{"2.4", "2.5", "-2.6", "2.4"}},
+	}
+
+	inputs := []string{"2.45", "2.55", "-2.55", "2.41"}
+	for _, tc := range tests {
+		for i, input := range inputs {
+			assert.Equal(t, tc.Expected[i], utils.MustParseDecimal(input).Round(1, tc.Mode).String(), "%d %s", tc.Mode, input)
+		}
+	}
+	assert.Equal(t, "2.4500", utils.MustParseDecimal("2.45").Round(4, utils.RoundDown).String())
+	assert.Equal(t, "1.01", utils.MustParseDecimal("1.005").StringFixed(2))
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	type holding struct {
+		Shares utils.Decimal     `json:"shares"`
+		Cost   utils.NullDecimal `json:"cost"`
+	}
+
+	var h holding
+	assert.Nil(t, json.Unmarshal([]byte(`{"shares": 12345678901234567890.123456789, "cost": null}`), &h))
+	assert.Equal(t, "12345678901234567890.123456789", h.Shares.String())
+	assert.False(t, h.Cost.Valid)
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"shares": "1.50", "cost": "100.25"}`), &h))
+	data, err := json.Marshal(h)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"shares":1.50,"cost":100.25}`, string(data))
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"shares": "abc"}`), &h))
+}
+
+func TestDecimal_SQL(t *testing.T) {
+	var d utils.Decimal
+	assert.Nil(t, d.Scan([]byte("-12.340")))
+	assert.Equal(t, "-12.340", d.String())
+	assert.Nil(t, d.Scan(int64(42)))
+	assert.Equal(t, "42", d.String())
+	assert.NotNil(t, d.Scan(nil))
+
+	value, err := utils.MustParseDecimal("1000.99").Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "1000.99", value)
+
+	var n utils.NullDecimal
+	assert.Nil(t, n.Scan(nil))
+	assert.False(t, n.Valid)
+	value, err = n.Value()
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+}
+
+func TestDecimal_PgxCodec(t *testing.T) {
+	m := pgtype.NewMap()
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		buf, err := m.Encode(pgtype.NumericOID, format, utils.MustParseDecimal("-98765432109876543210.0012"), nil)
+		assert.Nil(t, err)
+
+		var d utils.Decimal
+		assert.Nil(t, m.Scan(pgtype.NumericOID, format, buf, &d))
+		assert.Equal(t, "-98765432109876543210.0012", d.String())
+
+		var n utils.NullDecimal
+		assert.Nil(t, m.Scan(pgtype.NumericOID, format, nil, &n))
+		assert.False(t, n.Valid)
+		assert.NotNil(t, m.Scan(pgtype.NumericOID, format, nil, &d))
+	}
+}
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		Input    string
+		Currency string
+		Amount   string
+		Output   string
+	}{
+		{Input: "$1,000.99", Currency: "USD", Amount: "1000.99", Output: "$1,000.99"},
+		{Input: "-$1234567.5", Currency: "USD", Amount: "-1234567.5", Output: "-$1,234,567.50"},
+		{Input: "€12.50", Currency: "EUR", Amount: "12.50", Output: "€12.50"},
+		{Input: "5 KWD", Currency: "KWD", Amount: "5", Output: "5.000 KWD"},
+		{Input: "JPY 1500", Currency: "JPY", Amount: "1500", Output: "¥1,500"},
+		{Input: "N/A", Currency: "USD", Amount: "0", Output: "$0.00"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Input, func(t *testing.T) {
+			m, err := utils.ParseMoney(tc.Input, "")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Currency, m.Currency)
+			assert.Equal(t, tc.Amount, m.Amount.String())
+			assert.Equal(t, tc.Output, m.String())
+		})
+	}
+
+	price, _ := utils.ParseMoney("$345.67", "")
+	cost := price.Mul(utils.MustParseDecimal("3.333"))
+	assert.Equal(t, "1152.11811", cost.Amount.String())
+	assert.Equal(t, "1152.12", cost.Round(utils.RoundHalfEven).Amount.String())
+
+	total, err := cost.Add(price)
+	assert.Nil(t, err)
+	assert.Equal(t, "$1,497.79", total.String())
+	euros, _ := utils.ParseMoney("12.50", "eur")
+	_, err = total.Sub(euros)
+	assert.NotNil(t, err)
+
+	data, err := json.Marshal(euros)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"amount":12.50,"currency":"EUR"}`, string(data))
+	var m utils.Money
+	assert.Nil(t, json.Unmarshal([]byte(`{"amount":"0.10"}`), &m))
+	assert.Equal(t, utils.NewMoney(utils.MustParseDecimal("0.10"), ""), m)
+}
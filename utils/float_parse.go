@@ -7,7 +7,7 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// FloatParse common float parser
+// FloatParse common float parser.  Use ParseAmount for amounts that must be exact.
 func FloatParse(inputString string) (float64, error) {
 
 	t := strings.Replace(inputString, ",", "", -1)
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var errBadJSON = errors.New("invalid JSON")
+
+// DecodeJSON decodes a JSON document into maps, slices, strings, bools, nil and json.Numbers, which keep numbers as
+// they were written.
+func DecodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: data after the document", errBadJSON)
+	}
+	return value, nil
+}
+
+// normalizeJSON converts a Go value to the types DecodeJSON returns.
+func normalizeJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeJSON(data)
+}
+
+// CanonicalJSON returns the RFC 8785 canonical form of a JSON document: no whitespace, object members sorted by their
+// UTF-16 code units, numbers written as ECMAScript does and strings with the minimal escapes.  Equal documents have
+// identical canonical forms, so they can be hashed or compared as bytes.
+func CanonicalJSON(data []byte) ([]byte, error) {
+	value, err := DecodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err = writeCanonical(&b, value); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// MarshalCanonicalJSON marshals the value and returns its canonical form.
+func MarshalCanonicalJSON(value any) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalJSON(data)
+}
+
+func writeCanonical(b *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil || math.IsInf(f, 0) {
+			return fmt.Errorf("%w: number %s", errBadJSON, v)
+		}
+		b.WriteString(formatECMAScriptNumber(f))
+	case string:
+		return writeCanonicalString(b, v)
+	case []any:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeCanonical(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case map[string]any:
+		b.WriteByte('{')
+		for i, key := range sortedUTF16Keys(v) {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeCanonicalString(b, key); err != nil {
+				return err
+			}
+			b.WriteByte(':')
+			if err := writeCanonical(b, v[key]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("%w: unexpected %T", errBadJSON, value)
+	}
+	return nil
+}
+
+// sortedUTF16Keys sorts the keys by their UTF-16 code units, which differs from Go's byte order for characters
+// outside the Basic Multilingual Plane.
+func sortedUTF16Keys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return slices.Compare(utf16.Encode([]rune(keys[i])), utf16.Encode([]rune(keys[j]))) < 0
+	})
+	return keys
+}
+
+func writeCanonicalString(b *bytes.Buffer, s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("%w: string is not UTF-8", errBadJSON)
+	}
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return nil
+}
+
+// formatECMAScriptNumber formats a float as ECMAScript's Number.prototype.toString does, which RFC 8785 requires.
+func formatECMAScriptNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	// The shortest digits that round trip, and n, the position of the decimal point relative to them.
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(e, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, _ := strconv.Atoi(exponent)
+	n, k := exp+1, len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	s := digits[:1]
+	if k > 1 {
+		s += "." + digits[1:]
+	}
+	if n-1 >= 0 {
+		return sign + s + "e+" + strconv.Itoa(n-1)
+	}
+	return sign + s + "e" + strconv.Itoa(n-1)
+}
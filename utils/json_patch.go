@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	errBadPatch    = errors.New("invalid JSON patch")
+	errPatchTest   = errors.New("JSON patch test failed")
+	patchOperators = []string{"add", "remove", "replace", "move", "copy", "test"}
+)
+
+// PatchOperation is one RFC 6902 JSON Patch operation: add, remove, replace, move, copy or test.
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// MarshalJSON writes the value for the operations that have one, even when it is null, and from for move and copy,
+// even when it is "", the whole document.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	type operation PatchOperation
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			operation
+			Value any `json:"value"`
+		}{operation(o), o.Value})
+	case "move", "copy":
+		return json.Marshal(struct {
+			operation
+			From string `json:"from"`
+		}{operation(o), o.From})
+	}
+	return json.Marshal(operation(o))
+}
+
+// JSONPatch is an RFC 6902 JSON Patch, operations applied in order.
+type JSONPatch []PatchOperation
+
+// DiffJSON returns a patch that changes document a into document b.  Object members are compared by name and array
+// items by position, so the patch is correct but not always the shortest.
+func DiffJSON(a, b []byte) (JSONPatch, error) {
+	before, err := DecodeJSON(a)
+	if err != nil {
+		return nil, err
+	}
+	after, err := DecodeJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return diffJSON(nil, "", before, after), nil
+}
+
+func diffJSON(patch JSONPatch, path string, a, b any) JSONPatch {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for key := range av {
+			keys = append(keys, key)
+		}
+		for key := range bv {
+			if _, ok := av[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := path + FormatJSONPointer(key)
+			aValue, inA := av[key]
+			bValue, inB := bv[key]
+			switch {
+			case !inB:
+				patch = append(patch, PatchOperation{Op: "remove", Path: child})
+			case !inA:
+				patch = append(patch, PatchOperation{Op: "add", Path: child, Value: bValue})
+			default:
+				patch = diffJSON(patch, child, aValue, bValue)
+			}
+		}
+		return patch
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		common := min(len(av), len(bv))
+		for i := 0; i < common; i++ {
+			patch = diffJSON(patch, fmt.Sprintf("%s/%d", path, i), av[i], bv[i])
+		}
+		for i := common; i < len(bv); i++ {
+			patch = append(patch, PatchOperation{Op: "add", Path: fmt.Sprintf("%s/%d", path, i), Value: bv[i]})
+		}
+		// Remove from the end so the indexes of the items still to be removed do not change.
+		for i := len(av) - 1; i >= common; i-- {
+			patch = append(patch, PatchOperation{Op: "remove", Path: fmt.Sprintf("%s/%d", path, i)})
+		}
+		return patch
+	}
+
+	if !jsonEqual(a, b) {
+		patch = append(patch, PatchOperation{Op: "replace", Path: path, Value: b})
+	}
+	return patch
+}
+
+// jsonEqual compares decoded JSON values.  Numbers are equal when they have the same value, 1.0 and 1 are equal.
+func jsonEqual(a, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		ad, aErr := ParseDecimal(av.String())
+		bd, bErr := ParseDecimal(bv.String())
+		if aErr != nil || bErr != nil {
+			return av == bv
+		}
+		return ad.Equal(bd)
+	}
+	return a == b
+}
+
+// Apply applies the patch to a document and returns the patched document.  Either every operation is applied or,
+// when one fails, none are.
+func (p JSONPatch) Apply(data []byte) ([]byte, error) {
+	doc, err := DecodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = p.ApplyTo(doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// ApplyTo applies the patch to a document decoded by DecodeJSON and returns the patched document.  The document is
+// not changed.
+func (p JSONPatch) ApplyTo(doc any) (any, error) {
+	// The copy is patched so a failed operation does not leave the document half patched.
+	doc, err := normalizeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, o := range p {
+		if doc, err = o.apply(doc); err != nil {
+			return nil, fmt.Errorf("operation %d, %s %s: %w", i, o.Op, o.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func (o PatchOperation) apply(doc any) (any, error) {
+	if !Contains(patchOperators, o.Op) {
+		return nil, fmt.Errorf("%w: unknown op %q", errBadPatch, o.Op)
+	}
+
+	switch o.Op {
+	case "remove":
+		return removePointer(doc, o.Path)
+	case "move", "copy":
+		value, err := JSONPointerGet(doc, o.From)
+		if err != nil {
+			return nil, err
+		}
+		if o.Op == "move" {
+			if strings.HasPrefix(o.Path, o.From+"/") {
+				return nil, fmt.Errorf("%w: can not move %s into itself", errBadPatch, o.From)
+			}
+			if o.Path == o.From {
+				return doc, nil
+			}
+			if doc, err = removePointer(doc, o.From); err != nil {
+				return nil, err
+			}
+		} else if value, err = normalizeJSON(value); err != nil {
+			return nil, err
+		}
+		return setPointer(doc, o.Path, value, true)
+	}
+
+	value, err := normalizeJSON(o.Value)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Op {
+	case "add":
+		return setPointer(doc, o.Path, value, true)
+	case "replace":
+		if _, err = JSONPointerGet(doc, o.Path); err != nil {
+			return nil, err
+		}
+		return setPointer(doc, o.Path, value, false)
+	default:
+		current, err := JSONPointerGet(doc, o.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s", errPatchTest, o.Path)
+		}
+		return doc, nil
+	}
+}
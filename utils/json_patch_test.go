@@ -0,0 +1,205 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		Description string
+		Input       string
+		Expected    string
+	}{
+		{
+			Description: "Numbers",
+			Input:       `[1e30, 4.50, 2e-3, 0.000001, 1e-7, 333333333.33333329, -0, 100, 1E21, 123456789012345680000]`,
+			Expected:    `[1e+30,4.5,0.002,0.000001,1e-7,333333333.3333333,0,100,1e+21,123456789012345680000]`,
+		},
+		{
+			Description: "Strings",
+			Input:       `"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/<&>"`,
+			Expected:    "\"€$\\u000f\\nA'B\\\"\\\\\\\\\\\"/<&>\"",
+		},
+		{
+			Description: "Key Order",
+			Input: `{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\ud83d\ude00": 5, "\u0080": 6, "\u00f6": 7,
+				"nested": {"b": [true, null], "a": {}}}`,
+			Expected: "{\"\\r\":2,\"1\":4,\"nested\":{\"a\":{},\"b\":[true,null]},\"\u0080\":6,\"ö\":7,\"€\":1,\"😀\":5,\"\ufb33\":3}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Description, func(t *testing.T) {
+			result, err := utils.CanonicalJSON([]byte(tc.Input))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Expected, string(result))
+		})
+	}
+
+	_, err := utils.CanonicalJSON([]byte(`{"a": 1} {"b": 2}`))
+	assert.NotNil(t, err)
+	_, err = utils.CanonicalJSON([]byte(`1e400`))
+	assert.NotNil(t, err)
+
+	type doc struct {
+		B string `json:"b"`
+		A int    `json:"a"`
+	}
+	result, err := utils.MarshalCanonicalJSON(doc{B: "<x>", A: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":1,"b":"<x>"}`, string(result))
+}
+
+func TestJSONPointer(t *testing.T) {
+	doc, err := utils.DecodeJSON([]byte(`{"foo": ["bar", "baz"], "": 0, "a/b": 1, "m~n": 8, "k\"l": 6}`))
+	assert.Nil(t, err)
+
+	tests := map[string]any{
+		"":       doc,
+		"/foo/0": "bar",
+		"/":      json.Number("0"),
+		"/a~1b":  json.Number("1"),
+		"/m~0n":  json.Number("8"),
+		`/k"l`:   json.Number("6"),
+	}
+	for pointer, expected := range tests {
+		value, err := utils.JSONPointerGet(doc, pointer)
+		assert.Nil(t, err, pointer)
+		assert.Equal(t, expected, value, pointer)
+	}
+
+	for _, pointer := range []string{"foo", "/foo/2", "/foo/01", "/foo/-", "/missing", "/foo/0/x", "/m~2n"} {
+		_, err := utils.JSONPointerGet(doc, pointer)
+		assert.NotNil(t, err, pointer)
+	}
+
+	tokens, err := utils.ParseJSONPointer("/a~1b/~01")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a/b", "~1"}, tokens)
+	assert.Equal(t, "/a~1b/~01", utils.FormatJSONPointer(tokens...))
+
+	doc, err = utils.JSONPointerSet(doc, "/foo/-", "qux")
+	assert.Nil(t, err)
+	doc, err = utils.JSONPointerSet(doc, "/foo/0", "BAR")
+	assert.Nil(t, err)
+	doc, err = utils.JSONPointerSet(doc, "/new", map[string]any{})
+	assert.Nil(t, err)
+	doc, err = utils.JSONPointerSet(doc, "/new/x", true)
+	assert.Nil(t, err)
+	_, err = utils.JSONPointerSet(doc, "/nope/x", true)
+	assert.NotNil(t, err)
+
+	value, _ := utils.JSONPointerGet(doc, "/foo")
+	assert.Equal(t, []any{"BAR", "baz", "qux"}, value)
+	value, _ = utils.JSONPointerGet(doc, "/new/x")
+	assert.Equal(t, true, value)
+}
+
+func TestJSONPatch_Apply(t *testing.T) {
+	// Examples from RFC 6902 appendix A.
+	tests := []struct {
+		Description string
+		Doc         string
+		Patch       string
+		Expected    string
+		Error       bool
+	}{
+		{Description: "Add Member", Doc: `{"foo": "bar"}`, Patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			Expected: `{"baz":"qux","foo":"bar"}`},
+		{Description: "Add Item", Doc: `{"foo": ["bar", "baz"]}`, Patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			Expected: `{"foo":["bar","qux","baz"]}`},
+		{Description: "Remove Member", Doc: `{"baz": "qux", "foo": "bar"}`, Patch: `[{"op": "remove", "path": "/baz"}]`,
+			Expected: `{"foo":"bar"}`},
+		{Description: "Remove Item", Doc: `{"foo": ["bar", "qux", "baz"]}`, Patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			Expected: `{"foo":["bar","baz"]}`},
+		{Description: "Replace", Doc: `{"baz": "qux", "foo": "bar"}`, Patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			Expected: `{"baz":"boo","foo":"bar"}`},
+		{Description: "Move Member", Doc: `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			Patch:    `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			Expected: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{Description: "Move Item", Doc: `{"foo": ["all", "grass", "cows", "eat"]}`, Patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			Expected: `{"foo":["all","cows","eat","grass"]}`},
+		{Description: "Copy", Doc: `{"a": {"b": 1}}`, Patch: `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`,
+			Expected: `{"a":{"b":1},"c":{"b":2}}`},
+		{Description: "Test", Doc: `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			Patch:    `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2.0}]`,
+			Expected: `{"baz":"qux","foo":["a",2,"c"]}`},
+		{Description: "Test Fails", Doc: `{"baz": "qux"}`, Patch: `[{"op": "test", "path": "/baz", "value": "bar"}]`, Error: true},
+		{Description: "Add Nested Missing", Doc: `{"foo": "bar"}`, Patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, Error: true},
+		{Description: "Replace Missing", Doc: `{"foo": "bar"}`, Patch: `[{"op": "replace", "path": "/baz", "value": 1}]`, Error: true},
+		{Description: "Move Into Itself", Doc: `{"a": {"b": 1}}`, Patch: `[{"op": "move", "from": "/a", "path": "/a/c"}]`, Error: true},
+		{Description: "Unknown Op", Doc: `{}`, Patch: `[{"op": "merge", "path": "/a"}]`, Error: true},
+		{Description: "Add Null", Doc: `{}`, Patch: `[{"op": "add", "path": "/a", "value": null}]`, Expected: `{"a":null}`},
+		{Description: "Replace Root", Doc: `{"a": 1}`, Patch: `[{"op": "replace", "path": "", "value": [1]}]`, Expected: `[1]`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Description, func(t *testing.T) {
+			var patch utils.JSONPatch
+			assert.Nil(t, json.Unmarshal([]byte(tc.Patch), &patch))
+			result, err := patch.Apply([]byte(tc.Doc))
+			if tc.Error {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.JSONEq(t, tc.Expected, string(result))
+		})
+	}
+
+	// A failed patch leaves the document unchanged.
+	doc, _ := utils.DecodeJSON([]byte(`{"a": [1, 2]}`))
+	patch := utils.JSONPatch{{Op: "add", Path: "/a/-", Value: 3}, {Op: "test", Path: "/a/0", Value: 9}}
+	_, err := patch.ApplyTo(doc)
+	assert.NotNil(t, err)
+	value, _ := utils.JSONPointerGet(doc, "/a")
+	assert.Len(t, value, 2)
+}
+
+func TestDiffJSON(t *testing.T) {
+	before := `{"_id": "HD", "name": "Home Depot", "shares": 10.0, "lots": [{"n": 1}, {"n": 2}, {"n": 3}], "tags": ["a"],
+		"notes": null, "old": true}`
+	after := `{"_id": "HD", "name": "The Home Depot", "shares": 10, "lots": [{"n": 1}, {"n": 5}], "tags": ["a", "b", "c"],
+		"notes": {"x": 1}, "new": "yes"}`
+
+	patch, err := utils.DiffJSON([]byte(before), []byte(after))
+	assert.Nil(t, err)
+	data, err := json.Marshal(patch)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[
+		{"op": "replace", "path": "/lots/1/n", "value": 5},
+		{"op": "remove", "path": "/lots/2"},
+		{"op": "replace", "path": "/name", "value": "The Home Depot"},
+		{"op": "add", "path": "/new", "value": "yes"},
+		{"op": "replace", "path": "/notes", "value": {"x": 1}},
+		{"op": "remove", "path": "/old"},
+		{"op": "add", "path": "/tags/1", "value": "b"},
+		{"op": "add", "path": "/tags/2", "value": "c"}
+	]`, string(data))
+
+	patched, err := patch.Apply([]byte(before))
+	assert.Nil(t, err)
+	expected, _ := utils.CanonicalJSON([]byte(after))
+	actual, _ := utils.CanonicalJSON(patched)
+	assert.Equal(t, string(expected), string(actual))
+
+	patch, err = utils.DiffJSON([]byte(after), []byte(after))
+	assert.Nil(t, err)
+	assert.Empty(t, patch)
+
+	data, _ = json.Marshal(utils.JSONPatch{{Op: "replace", Path: "/a", Value: nil}, {Op: "remove", Path: "/b"}})
+	assert.Equal(t, `[{"op":"replace","path":"/a","value":null},{"op":"remove","path":"/b"}]`, string(data))
+
+	// "" is the whole document, so from is written for a copy of it.
+	data, _ = json.Marshal(utils.JSONPatch{{Op: "copy", From: "", Path: "/c"}})
+	assert.Equal(t, `[{"op":"copy","path":"/c","from":""}]`, string(data))
+	var copied utils.JSONPatch
+	assert.Nil(t, json.Unmarshal(data, &copied))
+	result, err := copied.Apply([]byte(`{"a": 1}`))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"a": 1, "c": {"a": 1}}`, string(result))
+}
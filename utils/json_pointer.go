@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errBadPointer     = errors.New("invalid JSON pointer")
+	errPointerMissing = errors.New("JSON pointer does not exist")
+)
+
+// ParseJSONPointer splits an RFC 6901 JSON Pointer like "/a/b~1c" into its unescaped tokens, ["a", "b/c"].  The empty
+// pointer is the whole document.
+func ParseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: %q does not start with /", errBadPointer, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		// ~1 is replaced before ~0, so "~01" is "~1" and not "/".
+		if strings.Contains(strings.NewReplacer("~0", "", "~1", "").Replace(token), "~") {
+			return nil, fmt.Errorf("%w: bad escape in %q", errBadPointer, pointer)
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// FormatJSONPointer joins tokens into a JSON Pointer, escaping "~" and "/".
+func FormatJSONPointer(tokens ...string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// arrayIndex parses an array index token.  "-", the index after the last item, is allowed when appending.
+func arrayIndex(token string, length int, appending bool) (int, error) {
+	if token == "-" && appending {
+		return length, nil
+	}
+	// Leading zeros and signs are not allowed.
+	if token == "" || len(token) > 1 && token[0] == '0' || strings.IndexFunc(token, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return 0, fmt.Errorf("%w: bad array index %q", errBadPointer, token)
+	}
+	i, err := strconv.Atoi(token)
+	limit := length - 1
+	if appending {
+		limit = length
+	}
+	if err != nil || i > limit {
+		return 0, fmt.Errorf("%w: array index %s out of range", errPointerMissing, token)
+	}
+	return i, nil
+}
+
+// JSONPointerGet returns the value the pointer refers to in a document decoded by DecodeJSON.
+func JSONPointerGet(doc any, pointer string) (any, error) {
+	tokens, err := ParseJSONPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	value := doc
+	for _, token := range tokens {
+		switch v := value.(type) {
+		case map[string]any:
+			child, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", errPointerMissing, pointer)
+			}
+			value = child
+		case []any:
+			i, err := arrayIndex(token, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			value = v[i]
+		default:
+			return nil, fmt.Errorf("%w: %s", errPointerMissing, pointer)
+		}
+	}
+	return value, nil
+}
+
+// JSONPointerSet sets the value the pointer refers to and returns the document, which is changed in place except when
+// the pointer is empty and the value replaces it.  The parent of the value must exist.  An object member is added or
+// replaced, an array item is replaced, or added at the end when the index is the array's length or "-".
+func JSONPointerSet(doc any, pointer string, value any) (any, error) {
+	return setPointer(doc, pointer, value, false)
+}
+
+// setPointer sets or, when inserting, adds the value, which moves the array items after it.
+func setPointer(doc any, pointer string, value any, inserting bool) (any, error) {
+	tokens, err := ParseJSONPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parentPointer := FormatJSONPointer(tokens[:len(tokens)-1]...)
+	parent, err := JSONPointerGet(doc, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = value
+		return doc, nil
+	case []any:
+		i, err := arrayIndex(last, len(p), true)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case i == len(p):
+			p = append(p, value)
+		case inserting:
+			p = append(p[:i], append([]any{value}, p[i:]...)...)
+		default:
+			p[i] = value
+			return doc, nil
+		}
+		// The array grew, so it is replaced in its own parent.
+		return setPointer(doc, parentPointer, p, false)
+	}
+	return nil, fmt.Errorf("%w: %s is not an object or array", errPointerMissing, parentPointer)
+}
+
+// removePointer removes the value the pointer refers to and returns the document.
+func removePointer(doc any, pointer string) (any, error) {
+	tokens, err := ParseJSONPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: can not remove the whole document", errBadPointer)
+	}
+
+	parentPointer := FormatJSONPointer(tokens[:len(tokens)-1]...)
+	parent, err := JSONPointerGet(doc, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+
+	switch p := parent.(type) {
+	case map[string]any:
+		if _, ok := p[last]; !ok {
+			return nil, fmt.Errorf("%w: %s", errPointerMissing, pointer)
+		}
+		delete(p, last)
+		return doc, nil
+	case []any:
+		i, err := arrayIndex(last, len(p), false)
+		if err != nil {
+			return nil, err
+		}
+		return setPointer(doc, parentPointer, append(p[:i:i], p[i+1:]...), false)
+	}
+	return nil, fmt.Errorf("%w: %s", errPointerMissing, pointer)
+}
@@ -1,10 +1,72 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	// unixEpochJDN is the Julian Day Number of 1970-01-01.
+	unixEpochJDN = 2440588
+	// mjdOffset is the Julian Day of the Modified Julian Day epoch, 1858-11-17 00:00 UTC.
+	mjdOffset = 2400000.5
+	// excelMaxSerial is 9999-12-31, the last date Excel supports.
+	excelMaxSerial = 2958465
+	millisPerDay   = 24 * 60 * 60 * 1000
+)
+
+var (
+	errBadDateCode   = errors.New("invalid date code")
+	errExcelLeapDay  = errors.New("excel serial 60 is 1900-02-29, which does not exist")
+	excel1900Epoch   = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	excel1904Epoch   = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+	excelLeapBugDate = time.Date(1900, time.March, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// DateCodeFormat is a way of writing a date as a number.
+type DateCodeFormat int
+
+const (
+	// DateCodeJulian is a year and day of the year, YYYYDDD, as JulDate writes.
+	DateCodeJulian DateCodeFormat = iota
+	// DateCodeShortJulian is the mainframe YYDDD, years 69 to 99 are 1969 to 1999 and 00 to 68 are 2000 to 2068.
+	DateCodeShortJulian
+	// DateCodeCenturyJulian is the mainframe CYYDDD, where C is 0 for the 1900s and 1 for the 2000s.
+	DateCodeCenturyJulian
+	// DateCodeJDN is an astronomical Julian Day Number.
+	DateCodeJDN
+	// DateCodeMJD is a Modified Julian Day, which may have a fraction for the time of day.
+	DateCodeMJD
+	// DateCodeExcel is an Excel serial date in the 1900 date system, which may have a fraction for the time of day.
+	DateCodeExcel
+	// DateCodeExcel1904 is an Excel serial date in the 1904 date system used by older Mac workbooks.
+	DateCodeExcel1904
+)
+
+func (f DateCodeFormat) String() string {
+	switch f {
+	case DateCodeJulian:
+		return "YYYYDDD"
+	case DateCodeShortJulian:
+		return "YYDDD"
+	case DateCodeCenturyJulian:
+		return "CYYDDD"
+	case DateCodeJDN:
+		return "JDN"
+	case DateCodeMJD:
+		return "MJD"
+	case DateCodeExcel:
+		return "Excel"
+	case DateCodeExcel1904:
+		return "Excel 1904"
+	}
+	return fmt.Sprintf("DateCodeFormat(%d)", int(f))
+}
+
 func JulDate() string {
 	return JulDateFromTime(time.Now())
 }
@@ -12,3 +74,184 @@ func JulDate() string {
 func JulDateFromTime(tm time.Time) string {
 	return fmt.Sprintf("%d%03d", tm.Year(), tm.YearDay())
 }
+
+// ShortJulDateFromTime returns the date as YYDDD.
+func ShortJulDateFromTime(tm time.Time) string {
+	return fmt.Sprintf("%02d%03d", tm.Year()%100, tm.YearDay())
+}
+
+// ordinalDate returns the day of the year as a date in UTC.
+func ordinalDate(year, day int) (time.Time, error) {
+	daysInYear := 365
+	if time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay() == 366 {
+		daysInYear = 366
+	}
+	if day < 1 || day > daysInYear {
+		return time.Time{}, fmt.Errorf("%w: day %d of %d", errBadDateCode, day, year)
+	}
+	return time.Date(year, time.January, day, 0, 0, 0, 0, time.UTC), nil
+}
+
+// ordinalDigits splits a code of exactly length digits into the digits before the day of the year and the day.
+func ordinalDigits(code string, length int) (int, int, error) {
+	code = strings.TrimSpace(code)
+	if len(code) != length || strings.IndexFunc(code, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return 0, 0, fmt.Errorf("%w: %q is not %d digits", errBadDateCode, code, length)
+	}
+	prefix, _ := strconv.Atoi(code[:length-3])
+	day, _ := strconv.Atoi(code[length-3:])
+	return prefix, day, nil
+}
+
+// ParseJulDate parses a YYYYDDD date, as written by JulDateFromTime.
+func ParseJulDate(code string) (time.Time, error) {
+	year, day, err := ordinalDigits(code, 7)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ordinalDate(year, day)
+}
+
+// ParseShortJulDate parses a YYDDD date.  Years 69 to 99 are 1969 to 1999 and 00 to 68 are 2000 to 2068, the same as
+// the time package.
+func ParseShortJulDate(code string) (time.Time, error) {
+	year, day, err := ordinalDigits(code, 5)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if year >= 69 {
+		year += 1900
+	} else {
+		year += 2000
+	}
+	return ordinalDate(year, day)
+}
+
+// ParseCenturyJulDate parses a CYYDDD date, where the year is 1900 + 100 * C + YY.
+func ParseCenturyJulDate(code string) (time.Time, error) {
+	year, day, err := ordinalDigits(code, 6)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ordinalDate(1900+year, day)
+}
+
+// civilDays returns the days from 1970-01-01 to t's date in its location.
+func civilDays(t time.Time) int64 {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// JulianDayNumber returns the Julian Day Number of t's date in its location.  Dates before 1582 are in the proleptic
+// Gregorian calendar, as in the time package, not the Julian calendar astronomers use for them.
+func JulianDayNumber(t time.Time) int64 {
+	return civilDays(t) + unixEpochJDN
+}
+
+// TimeFromJulianDayNumber returns the start of the date with the Julian Day Number in UTC.
+func TimeFromJulianDayNumber(jdn int64) time.Time {
+	return time.Unix((jdn-unixEpochJDN)*86400, 0).UTC()
+}
+
+// JulianDay returns the astronomical Julian Day of the instant, which begins at noon UTC.
+func JulianDay(t time.Time) float64 {
+	return float64(t.UnixMilli())/millisPerDay + unixEpochJDN - 0.5
+}
+
+// TimeFromJulianDay returns the instant of the astronomical Julian Day in UTC, to the millisecond.
+func TimeFromJulianDay(jd float64) time.Time {
+	return time.UnixMilli(int64(math.Round((jd - unixEpochJDN + 0.5) * millisPerDay))).UTC()
+}
+
+// ModifiedJulianDay returns the Modified Julian Day of the instant, the days since 1858-11-17 00:00 UTC.
+func ModifiedJulianDay(t time.Time) float64 {
+	return JulianDay(t) - mjdOffset
+}
+
+// TimeFromModifiedJulianDay returns the instant of the Modified Julian Day in UTC, to the millisecond.
+func TimeFromModifiedJulianDay(mjd float64) time.Time {
+	return TimeFromJulianDay(mjd + mjdOffset)
+}
+
+// ExcelSerial returns the Excel serial date of t's date and time of day in its location.  The 1900 date system counts
+// 1900-02-29, which did not exist, so dates before March 1900 are one less than the days since 1899-12-30.
+func ExcelSerial(t time.Time, date1904 bool) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	epoch := excel1900Epoch
+	if date1904 {
+		epoch = excel1904Epoch
+	} else if wall.Before(excelLeapBugDate) {
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	return float64(wall.Sub(epoch).Milliseconds()) / millisPerDay
+}
+
+// TimeFromExcelSerial returns the date and time of an Excel serial date, in UTC to the millisecond.  In the 1900 date
+// system serial 60, the 1900-02-29 Excel shows, is an error.
+func TimeFromExcelSerial(serial float64, date1904 bool) (time.Time, error) {
+	if math.IsNaN(serial) || serial < 0 || serial >= excelMaxSerial+1 {
+		return time.Time{}, fmt.Errorf("%w: excel serial %v", errBadDateCode, serial)
+	}
+
+	epoch := excel1900Epoch
+	switch {
+	case date1904:
+		epoch = excel1904Epoch
+	case serial >= 60 && serial < 61:
+		return time.Time{}, errExcelLeapDay
+	case serial < 60:
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+
+	days := math.Floor(serial)
+	millis := math.Round((serial - days) * millisPerDay)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(millis) * time.Millisecond), nil
+}
+
+// ParseDateCode parses a date code in the first of the formats it is valid in.  Formats are tried in order, so list
+// the most likely first: many numbers are valid in several formats.
+func ParseDateCode(code string, formats ...DateCodeFormat) (time.Time, error) {
+	code = strings.TrimSpace(code)
+	var errs []error
+	for _, format := range formats {
+		t, err := parseDateCode(code, format)
+		if err == nil {
+			return t, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", format, err))
+	}
+	if len(errs) == 0 {
+		return time.Time{}, fmt.Errorf("%w: %q, no formats", errBadDateCode, code)
+	}
+	return time.Time{}, errors.Join(errs...)
+}
+
+func parseDateCode(code string, format DateCodeFormat) (time.Time, error) {
+	switch format {
+	case DateCodeJulian:
+		return ParseJulDate(code)
+	case DateCodeShortJulian:
+		return ParseShortJulDate(code)
+	case DateCodeCenturyJulian:
+		return ParseCenturyJulDate(code)
+	case DateCodeJDN:
+		jdn, err := strconv.ParseInt(code, 10, 64)
+		if err != nil || jdn < 0 {
+			return time.Time{}, fmt.Errorf("%w: %q", errBadDateCode, code)
+		}
+		return TimeFromJulianDayNumber(jdn), nil
+	}
+
+	value, err := strconv.ParseFloat(code, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return time.Time{}, fmt.Errorf("%w: %q", errBadDateCode, code)
+	}
+	switch format {
+	case DateCodeMJD:
+		return TimeFromModifiedJulianDay(value), nil
+	case DateCodeExcel:
+		return TimeFromExcelSerial(value, false)
+	case DateCodeExcel1904:
+		return TimeFromExcelSerial(value, true)
+	}
+	return time.Time{}, fmt.Errorf("%w: unknown format %s", errBadDateCode, format)
+}
@@ -0,0 +1,126 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseJulDates(t *testing.T) {
+	tests := []struct {
+		Description string
+		Parse       func(string) (time.Time, error)
+		Input       string
+		Expected    time.Time
+		Error       bool
+	}{
+		{Description: "YYYYDDD", Parse: utils.ParseJulDate, Input: "2024171", Expected: date(2024, 6, 19)},
+		{Description: "Leap Day 366", Parse: utils.ParseJulDate, Input: "2024366", Expected: date(2024, 12, 31)},
+		{Description: "Not Leap Year", Parse: utils.ParseJulDate, Input: "2023366", Error: true},
+		{Description: "Day Zero", Parse: utils.ParseJulDate, Input: "2024000", Error: true},
+		{Description: "Too Short", Parse: utils.ParseJulDate, Input: "202417", Error: true},
+		{Description: "YYDDD", Parse: utils.ParseShortJulDate, Input: "24171", Expected: date(2024, 6, 19)},
+		{Description: "YYDDD 1900s", Parse: utils.ParseShortJulDate, Input: "99365", Expected: date(1999, 12, 31)},
+		{Description: "YYDDD Pivot", Parse: utils.ParseShortJulDate, Input: "68001", Expected: date(2068, 1, 1)},
+		{Description: "YYDDD Junk", Parse: utils.ParseShortJulDate, Input: "24A71", Error: true},
+		{Description: "CYYDDD", Parse: utils.ParseCenturyJulDate, Input: "124171", Expected: date(2024, 6, 19)},
+		{Description: "CYYDDD 1900s", Parse: utils.ParseCenturyJulDate, Input: "099001", Expected: date(1999, 1, 1)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Description, func(t *testing.T) {
+			result, err := tc.Parse(tc.Input)
+			if tc.Error {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Expected, result)
+		})
+	}
+
+	// Formatting and parsing round trip.
+	now := date(2024, 2, 29)
+	parsed, err := utils.ParseJulDate(utils.JulDateFromTime(now))
+	assert.Nil(t, err)
+	assert.Equal(t, now, parsed)
+	assert.Equal(t, "24060", utils.ShortJulDateFromTime(now))
+}
+
+func TestJulianDays(t *testing.T) {
+	y2k := date(2000, 1, 1)
+	assert.Equal(t, int64(2451545), utils.JulianDayNumber(y2k))
+	assert.Equal(t, y2k, utils.TimeFromJulianDayNumber(2451545))
+	assert.Equal(t, int64(2299161), utils.JulianDayNumber(date(1582, 10, 15)))
+
+	// The astronomical Julian Day begins at noon.
+	noon := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 2451545.0, utils.JulianDay(noon))
+	assert.Equal(t, noon, utils.TimeFromJulianDay(2451545.0))
+
+	assert.Equal(t, 51544.0, utils.ModifiedJulianDay(y2k))
+	assert.Equal(t, 51544.5, utils.ModifiedJulianDay(noon))
+	assert.Equal(t, noon, utils.TimeFromModifiedJulianDay(51544.5))
+}
+
+func TestExcelSerial(t *testing.T) {
+	tests := []struct {
+		Serial   float64
+		Date1904 bool
+		Expected time.Time
+		Error    bool
+	}{
+		{Serial: 1, Expected: date(1900, 1, 1)},
+		{Serial: 59, Expected: date(1900, 2, 28)},
+		{Serial: 60, Error: true},
+		{Serial: 61, Expected: date(1900, 3, 1)},
+		{Serial: 45292, Expected: date(2024, 1, 1)},
+		{Serial: 45462.75, Expected: time.Date(2024, 6, 19, 18, 0, 0, 0, time.UTC)},
+		{Serial: 43830, Date1904: true, Expected: date(2024, 1, 1)},
+		{Serial: 0, Date1904: true, Expected: date(1904, 1, 1)},
+		{Serial: -1, Error: true},
+		{Serial: 3000000, Error: true},
+	}
+
+	for _, tc := range tests {
+		result, err := utils.TimeFromExcelSerial(tc.Serial, tc.Date1904)
+		if tc.Error {
+			assert.NotNil(t, err, tc.Serial)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, tc.Expected, result, tc.Serial)
+		assert.Equal(t, tc.Serial, utils.ExcelSerial(result, tc.Date1904), tc.Expected)
+	}
+}
+
+func TestParseDateCode(t *testing.T) {
+	result, err := utils.ParseDateCode("2024171", utils.DateCodeJulian, utils.DateCodeExcel)
+	assert.Nil(t, err)
+	assert.Equal(t, date(2024, 6, 19), result)
+
+	// 45462 is not a valid YYDDD, day 462, so the Excel format is used.
+	result, err = utils.ParseDateCode(" 45462 ", utils.DateCodeShortJulian, utils.DateCodeExcel)
+	assert.Nil(t, err)
+	assert.Equal(t, date(2024, 6, 19), result)
+
+	result, err = utils.ParseDateCode("60480", utils.DateCodeMJD)
+	assert.Nil(t, err)
+	assert.Equal(t, date(2024, 6, 19), result)
+
+	result, err = utils.ParseDateCode("2460481", utils.DateCodeJDN)
+	assert.Nil(t, err)
+	assert.Equal(t, date(2024, 6, 19), result)
+
+	_, err = utils.ParseDateCode("junk", utils.DateCodeJulian, utils.DateCodeJDN, utils.DateCodeExcel1904)
+	assert.ErrorContains(t, err, "YYYYDDD")
+	assert.ErrorContains(t, err, "Excel 1904")
+	_, err = utils.ParseDateCode("2024171")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultCurrency is the currency of amounts that do not name one.
+const DefaultCurrency = "USD"
+
+var errCurrencyMismatch = errors.New("currencies do not match")
+
+// currency is how an ISO 4217 currency is written.
+type currency struct {
+	symbol string
+	places int32
+}
+
+// currencies are the symbols and minor units of common currencies.  Others are written with their code and two places.
+var currencies = map[string]currency{
+	"USD": {"$", 2},
+	"EUR": {"€", 2},
+	"GBP": {"£", 2},
+	"JPY": {"¥", 0},
+	"CAD": {"CA$", 2},
+	"AUD": {"A$", 2},
+	"CHF": {"", 2},
+	"KWD": {"", 3},
+	"BHD": {"", 3},
+}
+
+// currencySymbols are the symbols parsed as a currency, "$" is the default currency.
+var currencySymbols = map[string]string{"€": "EUR", "£": "GBP", "¥": "JPY", "CA$": "CAD", "A$": "AUD"}
+
+// Money is an exact amount in a currency, named by its ISO 4217 code.
+type Money struct {
+	Amount   Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// NewMoney returns the amount in the currency, DefaultCurrency when it is empty.
+func NewMoney(amount Decimal, code string) Money {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		code = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: code}
+}
+
+// ParseMoney parses an amount like "$1,000.99", "€12.50", "USD 5" or "5 EUR".  A currency code or symbol in the
+// input wins over code, and "$" is code.  The amount is parsed by ParseAmount.
+func ParseMoney(s, code string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	if len(s) > 3 && isCurrencyCode(s[:3]) {
+		code, s = s[:3], s[3:]
+	} else if len(s) > 3 && isCurrencyCode(s[len(s)-3:]) {
+		code, s = s[len(s)-3:], s[:len(s)-3]
+	}
+	for symbol, c := range currencySymbols {
+		if strings.HasPrefix(s, symbol) {
+			code, s = c, strings.TrimPrefix(s, symbol)
+			break
+		}
+	}
+
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return Money{}, err
+	}
+	if negative {
+		amount = amount.Neg()
+	}
+	return NewMoney(amount, code), nil
+}
+
+func isCurrencyCode(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Places returns the number of digits after the decimal point the currency uses.
+func (m Money) Places() int32 {
+	if c, ok := currencies[m.Currency]; ok {
+		return c.places
+	}
+	return 2
+}
+
+func (m Money) check(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", errCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+// Add returns m + o, which must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.check(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount.Add(o.Amount), Currency: m.Currency}, nil
+}
+
+// Sub returns m - o, which must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.check(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount.Sub(o.Amount), Currency: m.Currency}, nil
+}
+
+// Cmp compares m and o, which must be in the same currency, see Decimal.Cmp.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.check(o); err != nil {
+		return 0, err
+	}
+	return m.Amount.Cmp(o.Amount), nil
+}
+
+// Mul returns m times a quantity, like a price times shares, exactly.
+func (m Money) Mul(d Decimal) Money {
+	return Money{Amount: m.Amount.Mul(d), Currency: m.Currency}
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{Amount: m.Amount.Neg(), Currency: m.Currency}
+}
+
+// IsZero reports whether the amount is 0.
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// Round returns m rounded to the currency's minor unit, cents for dollars.
+func (m Money) Round(mode RoundingMode) Money {
+	return Money{Amount: m.Amount.Round(m.Places(), mode), Currency: m.Currency}
+}
+
+// String formats m rounded half up to the currency's minor unit with grouped thousands, like "-$1,000.99", or
+// "1,000.990 KWD" for currencies without a symbol.
+func (m Money) String() string {
+	rounded := m.Amount.Round(m.Places(), RoundHalfUp)
+	digits := rounded.Abs().String()
+	whole, fraction, hasFraction := strings.Cut(digits, ".")
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if hasFraction {
+		b.WriteString("." + fraction)
+	}
+
+	sign := ""
+	if rounded.Sign() < 0 {
+		sign = "-"
+	}
+	if c, ok := currencies[m.Currency]; ok && c.symbol != "" {
+		return sign + c.symbol + b.String()
+	}
+	return strings.TrimRightFunc(sign+b.String()+" "+m.Currency, unicode.IsSpace)
+}
+
+// UnmarshalJSON reads {"amount": ..., "currency": ...}, the currency defaulting to DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	type money Money
+	var value money
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*m = NewMoney(value.Amount, value.Currency)
+	return nil
+}
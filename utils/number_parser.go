@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var errBadNumber = errors.New("invalid number")
+
+// NumberLocale is how a locale writes the decimal point and groups digits.
+type NumberLocale struct {
+	Decimal rune
+	// Group are the characters that may separate groups of digits.
+	Group string
+}
+
+var (
+	// LocaleUS writes 1,234.56.
+	LocaleUS = NumberLocale{Decimal: '.', Group: ","}
+	// LocaleEurope writes 1.234,56, as in Germany, Italy and Spain.
+	LocaleEurope = NumberLocale{Decimal: ',', Group: "."}
+	// LocaleFrance writes 1 234,56 with a space, or a no-break space, between groups.
+	LocaleFrance = NumberLocale{Decimal: ',', Group: " \u00a0\u202f"}
+	// LocaleSwiss writes 1'234.56.
+	LocaleSwiss = NumberLocale{Decimal: '.', Group: "'\u2019"}
+)
+
+// DefaultNullTokens are the values NewNumberParser treats as missing.  They include those FloatParse treats as 0.
+var DefaultNullTokens = []string{"", "N/A", "NA", "-", "--", "null", "Add"}
+
+// DefaultSuffixes are the multipliers NewNumberParser accepts after a number, as in quote feeds.
+var DefaultSuffixes = map[string]Decimal{
+	"K": DecimalFromInt(1_000),
+	"M": DecimalFromInt(1_000_000),
+	"B": DecimalFromInt(1_000_000_000),
+	"T": DecimalFromInt(1_000_000_000_000),
+}
+
+// NumberParser parses numbers as they appear in spreadsheets, statements and quote feeds: with currency symbols or
+// codes, grouped digits, a sign before or after the number, accounting negatives like "(1,234.56)", percents and
+// suffixes like "1.2M".  Numbers are parsed exactly as Decimals.
+type NumberParser struct {
+	Locale NumberLocale
+	// NullTokens are the values, ignoring case and surrounding space, that are missing.
+	NullTokens []string
+	// MissingIsZero returns 0 for the NullTokens, like FloatParse, instead of a missing value.
+	MissingIsZero bool
+	// Suffixes are multipliers that may follow the number, matched ignoring case.  Nil allows none.
+	Suffixes map[string]Decimal
+	// PercentAsFraction parses "12.5%" as 0.125 instead of 12.5.
+	PercentAsFraction bool
+}
+
+// NewNumberParser returns a parser for the locale with DefaultNullTokens and DefaultSuffixes.
+func NewNumberParser(locale NumberLocale) *NumberParser {
+	return &NumberParser{Locale: locale, NullTokens: DefaultNullTokens, Suffixes: DefaultSuffixes}
+}
+
+func (p *NumberParser) isNull(s string) bool {
+	for _, token := range p.NullTokens {
+		if strings.EqualFold(s, strings.TrimSpace(token)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses s.  The result is not Valid when s is one of the NullTokens, unless MissingIsZero is set.
+func (p *NumberParser) Parse(s string) (NullDecimal, error) {
+	t := strings.TrimSpace(s)
+	if p.isNull(t) {
+		return NullDecimal{Valid: p.MissingIsZero}, nil
+	}
+
+	negatives := 0
+	t = strings.Map(func(r rune) rune {
+		// Unicode minus signs and dashes are written for negatives by some software.
+		if r == '\u2212' || r == '\u2012' || r == '\u2013' {
+			return '-'
+		}
+		return r
+	}, t)
+	if strings.HasPrefix(t, "(") && strings.HasSuffix(t, ")") {
+		negatives++
+		t = t[1 : len(t)-1]
+	}
+
+	t = p.stripCurrency(t)
+	if strings.HasPrefix(t, "-") {
+		negatives++
+		t = t[1:]
+	} else if strings.HasPrefix(t, "+") {
+		t = t[1:]
+	}
+	if strings.HasSuffix(t, "-") {
+		negatives++
+		t = t[:len(t)-1]
+	}
+	// A sign may come before or after the currency symbol, "-$5" or "$-5".
+	t = p.stripCurrency(t)
+	if strings.HasPrefix(t, "-") {
+		negatives++
+		t = t[1:]
+	}
+	if negatives > 1 {
+		return NullDecimal{}, fmt.Errorf("%w: %q", errBadNumber, s)
+	}
+
+	percent := false
+	t = strings.TrimSpace(strings.TrimPrefix(t, "#"))
+	if strings.HasSuffix(t, "%") {
+		percent = true
+		t = strings.TrimSpace(t[:len(t)-1])
+	}
+
+	multiplier, t := p.stripSuffix(t)
+	number, err := p.normalize(t)
+	if err != nil {
+		return NullDecimal{}, fmt.Errorf("%w: %q", errBadNumber, s)
+	}
+	value, err := parseDecimal(number)
+	if err != nil {
+		return NullDecimal{}, fmt.Errorf("%w: %q", errBadNumber, s)
+	}
+
+	if multiplier != nil {
+		value = value.Mul(*multiplier)
+	}
+	if percent && p.PercentAsFraction {
+		value = value.Mul(NewDecimal(1, 2))
+	}
+	if negatives == 1 {
+		value = value.Neg()
+	}
+	return NullDecimal{Decimal: value, Valid: true}, nil
+}
+
+// ParseFloat parses s like Parse and returns it as a float64.  ok is false when s is missing.
+func (p *NumberParser) ParseFloat(s string) (value float64, ok bool, err error) {
+	d, err := p.Parse(s)
+	if err != nil || !d.Valid {
+		return 0, false, err
+	}
+	return d.Decimal.Float64(), true, nil
+}
+
+// stripCurrency removes currency symbols and a currency code from either end of s.
+func (p *NumberParser) stripCurrency(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimFunc(s, func(r rune) bool {
+		return unicode.Is(unicode.Sc, r) || unicode.IsSpace(r)
+	})
+	if len(s) > 3 && isCurrencyCode(s[:3]) && !unicode.IsLetter(rune(s[3])) {
+		s = s[3:]
+	} else if len(s) > 3 && isCurrencyCode(s[len(s)-3:]) && !unicode.IsLetter(rune(s[len(s)-4])) {
+		s = s[:len(s)-3]
+	}
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.Is(unicode.Sc, r) || unicode.IsSpace(r)
+	})
+}
+
+// stripSuffix removes a multiplier suffix from s and returns it, the longest matching suffix first.
+func (p *NumberParser) stripSuffix(s string) (*Decimal, string) {
+	suffixes := make([]string, 0, len(p.Suffixes))
+	for suffix := range p.Suffixes {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Slice(suffixes, func(i, j int) bool {
+		return len(suffixes[i]) > len(suffixes[j])
+	})
+
+	for _, suffix := range suffixes {
+		if len(s) > len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+			multiplier := p.Suffixes[suffix]
+			return &multiplier, strings.TrimSpace(s[:len(s)-len(suffix)])
+		}
+	}
+	return nil, s
+}
+
+// normalize removes the group separators and makes the decimal point a '.'.  Group separators after the decimal point
+// and more than one decimal point are errors, which catches numbers written for another locale.
+func (p *NumberParser) normalize(s string) (string, error) {
+	decimal := p.Locale.Decimal
+	if decimal == 0 {
+		decimal = '.'
+	}
+
+	var b strings.Builder
+	seenDecimal := false
+	for _, r := range s {
+		switch {
+		case r == decimal:
+			if seenDecimal {
+				return "", errBadNumber
+			}
+			seenDecimal = true
+			b.WriteRune('.')
+		case strings.ContainsRune(p.Locale.Group, r):
+			if seenDecimal {
+				return "", errBadNumber
+			}
+		case r == '.':
+			// A '.' that is neither the decimal point nor a group separator is not a number in this locale.
+			return "", errBadNumber
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
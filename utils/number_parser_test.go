@@ -0,0 +1,91 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberParser_Parse(t *testing.T) {
+	tests := []struct {
+		Description string
+		Locale      utils.NumberLocale
+		Input       string
+		Expected    string
+		Missing     bool
+		Error       bool
+	}{
+		{Description: "Plain", Locale: utils.LocaleUS, Input: "1234.56", Expected: "1234.56"},
+		{Description: "Currency", Locale: utils.LocaleUS, Input: "$1,000.99", Expected: "1000.99"},
+		{Description: "Accounting Negative", Locale: utils.LocaleUS, Input: "(1,234.56)", Expected: "-1234.56"},
+		{Description: "Accounting Currency", Locale: utils.LocaleUS, Input: "($1,234.56)", Expected: "-1234.56"},
+		{Description: "Trailing Minus", Locale: utils.LocaleUS, Input: "1,234.56-", Expected: "-1234.56"},
+		{Description: "Minus Before Symbol", Locale: utils.LocaleUS, Input: "-$5.00", Expected: "-5.00"},
+		{Description: "Minus After Symbol", Locale: utils.LocaleUS, Input: "$-5.00", Expected: "-5.00"},
+		{Description: "Unicode Minus", Locale: utils.LocaleUS, Input: "−42", Expected: "-42"},
+		{Description: "Double Negative", Locale: utils.LocaleUS, Input: "(-5)", Error: true},
+		{Description: "Currency Code", Locale: utils.LocaleUS, Input: "USD 1,000", Expected: "1000"},
+		{Description: "Euro", Locale: utils.LocaleEurope, Input: "1.234,56 €", Expected: "1234.56"},
+		{Description: "Euro Code", Locale: utils.LocaleEurope, Input: "EUR -1.234,56", Expected: "-1234.56"},
+		{Description: "French", Locale: utils.LocaleFrance, Input: "1 234,56", Expected: "1234.56"},
+		{Description: "Swiss", Locale: utils.LocaleSwiss, Input: "CHF 1'234.50", Expected: "1234.50"},
+		{Description: "Pound", Locale: utils.LocaleUS, Input: "£12", Expected: "12"},
+		{Description: "Wrong Locale", Locale: utils.LocaleUS, Input: "1.234,56", Error: true},
+		{Description: "Wrong Locale Europe", Locale: utils.LocaleEurope, Input: "1,234.56", Error: true},
+		{Description: "Millions", Locale: utils.LocaleUS, Input: "1.2M", Expected: "1200000.0"},
+		{Description: "Thousands", Locale: utils.LocaleUS, Input: "3.4k", Expected: "3400.0"},
+		{Description: "Billions", Locale: utils.LocaleUS, Input: "-$2.5 B", Expected: "-2500000000.0"},
+		{Description: "Percent", Locale: utils.LocaleUS, Input: "12.5%", Expected: "12.5"},
+		{Description: "N/A", Locale: utils.LocaleUS, Input: "N/A", Missing: true},
+		{Description: "Dashes", Locale: utils.LocaleUS, Input: " -- ", Missing: true},
+		{Description: "Empty", Locale: utils.LocaleUS, Input: "", Missing: true},
+		{Description: "Junk", Locale: utils.LocaleUS, Input: "Junk", Error: true},
+		{Description: "Empty Exponent", Locale: utils.LocaleUS, Input: "2E", Error: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Description, func(t *testing.T) {
+			result, err := utils.NewNumberParser(tc.Locale).Parse(tc.Input)
+			if tc.Error {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, !tc.Missing, result.Valid)
+			if !tc.Missing {
+				assert.Equal(t, tc.Expected, result.Decimal.String())
+			}
+		})
+	}
+}
+
+func TestNumberParser_Options(t *testing.T) {
+	parser := utils.NewNumberParser(utils.LocaleUS)
+	parser.MissingIsZero = true
+	parser.PercentAsFraction = true
+	parser.Suffixes = nil
+	parser.NullTokens = []string{"none"}
+
+	result, err := parser.Parse("NONE")
+	assert.Nil(t, err)
+	assert.True(t, result.Valid)
+	assert.True(t, result.Decimal.IsZero())
+
+	result, err = parser.Parse("12.5%")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.125", result.Decimal.String())
+
+	_, err = parser.Parse("1.2M")
+	assert.NotNil(t, err)
+	_, err = parser.Parse("N/A")
+	assert.NotNil(t, err)
+
+	value, ok, err := utils.NewNumberParser(utils.LocaleUS).ParseFloat("N/A")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	value, ok, err = utils.NewNumberParser(utils.LocaleUS).ParseFloat("(0.5)")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, -0.5, value)
+}
@@ -0,0 +1,97 @@
+package utils
+
+// Set is a set of comparable values.  The zero value is an empty set that can be read but not added to, use NewSet.
+type Set[T comparable] map[T]struct{}
+
+// NewSet returns a set of the items.
+func NewSet[T comparable](items ...T) Set[T] {
+	s := make(Set[T], len(items))
+	s.Add(items...)
+	return s
+}
+
+// Add adds the items to the set.
+func (s Set[T]) Add(items ...T) {
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+}
+
+// Remove removes the items from the set.
+func (s Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		delete(s, item)
+	}
+}
+
+// Has reports whether the item is in the set.
+func (s Set[T]) Has(item T) bool {
+	_, ok := s[item]
+	return ok
+}
+
+// Len returns the number of items in the set.
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+// Items returns the items in no particular order, use SortedKeys for a sorted slice.
+func (s Set[T]) Items() []T {
+	items := make([]T, 0, len(s))
+	for item := range s {
+		items = append(items, item)
+	}
+	return items
+}
+
+// Union returns a new set of the items in either set.
+func (s Set[T]) Union(o Set[T]) Set[T] {
+	union := make(Set[T], len(s)+len(o))
+	for item := range s {
+		union[item] = struct{}{}
+	}
+	for item := range o {
+		union[item] = struct{}{}
+	}
+	return union
+}
+
+// Intersection returns a new set of the items in both sets.
+func (s Set[T]) Intersection(o Set[T]) Set[T] {
+	if len(o) < len(s) {
+		s, o = o, s
+	}
+	intersection := make(Set[T])
+	for item := range s {
+		if o.Has(item) {
+			intersection[item] = struct{}{}
+		}
+	}
+	return intersection
+}
+
+// Difference returns a new set of the items in s that are not in o.
+func (s Set[T]) Difference(o Set[T]) Set[T] {
+	difference := make(Set[T])
+	for item := range s {
+		if !o.Has(item) {
+			difference[item] = struct{}{}
+		}
+	}
+	return difference
+}
+
+// IsSubset reports whether every item in s is in o.
+func (s Set[T]) IsSubset(o Set[T]) bool {
+	for item := range s {
+		if !o.Has(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether the sets have the same items.
+func (s Set[T]) Equal(o Set[T]) bool {
+	return len(s) == len(o) && s.IsSubset(o)
+}